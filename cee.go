@@ -0,0 +1,244 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// ceeCookie precedes the JSON object in the message of the CEE format.
+const ceeCookie = "@cee: "
+
+// ceeFormat outputs a message in the BSD syslog format with the message being a
+// JSON object prefixed by the CEE cookie. The object holds the level, source,
+// message and attributes, with groups written as nested objects. Values are
+// encoded just like [log/slog.JSONHandler] does.
+func ceeFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = appendRFC3164Header(buf, r, opts)
+	buf = append(buf, ceeCookie...)
+	buf = appendCEE(buf, r, opts)
+
+	return append(buf, '\n')
+}
+
+// appendCEE adds the record as a JSON object.
+func appendCEE(buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = append(buf, '{')
+	buf = appendJSONKey(buf, slog.LevelKey)
+	buf = appendJSONString(buf, r.Level.String())
+
+	if source := recordSource(r, opts); source != nil {
+		buf = appendJSONKey(buf, slog.SourceKey)
+		buf = append(buf, '{')
+		buf = appendJSONKey(buf, "function")
+		buf = appendJSONString(buf, source.Function)
+		buf = appendJSONKey(buf, "file")
+		buf = appendJSONString(buf, source.File)
+		buf = appendJSONKey(buf, "line")
+		buf = strconv.AppendInt(buf, int64(source.Line), 10)
+		buf = append(buf, '}')
+	}
+
+	buf = appendJSONKey(buf, slog.MessageKey)
+	buf = appendJSONString(buf, r.Message)
+
+	// Just like the JSON handler, groups that end up without any attributes
+	// aren't written at all.
+	goas := opts.Groups
+	if r.NumAttrs() == 0 {
+		for len(goas) > 0 && goas[len(goas)-1].group != "" {
+			goas = goas[:len(goas)-1]
+		}
+	}
+
+	groups := 0
+	for _, goa := range goas {
+		if goa.group != "" {
+			buf = appendJSONKey(buf, goa.group)
+			buf = append(buf, '{')
+			groups++
+			continue
+		}
+
+		for _, a := range goa.attrs {
+			buf = appendJSONAttr(buf, a, 0)
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		buf = appendJSONAttr(buf, a, 0)
+		return true
+	})
+
+	for i := 0; i < groups; i++ {
+		buf = append(buf, '}')
+	}
+
+	return append(buf, '}')
+}
+
+// appendJSONAttr adds the attribute as a member of the JSON object being
+// written. Groups become nested objects unless their key is empty, in which
+// case their attributes are inlined.
+func appendJSONAttr(buf []byte, a slog.Attr, depth int) []byte {
+	a.Value = limitDepth(resolveValue(a.Value), depth)
+	if a.Equal(slog.Attr{}) {
+		return buf
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		buf = appendJSONKey(buf, a.Key)
+		return appendJSONValue(buf, a.Value)
+	}
+
+	attrs := a.Value.Group()
+	if len(attrs) == 0 {
+		return buf
+	}
+
+	if a.Key != "" {
+		buf = appendJSONKey(buf, a.Key)
+		buf = append(buf, '{')
+	}
+	for _, ga := range attrs {
+		buf = appendJSONAttr(buf, ga, depth+1)
+	}
+	if a.Key != "" {
+		buf = append(buf, '}')
+	}
+
+	return buf
+}
+
+// appendJSONKey adds the object key along with a separating comma unless it is
+// the object's first member.
+func appendJSONKey(buf []byte, key string) []byte {
+	if len(buf) > 0 && buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	buf = appendJSONString(buf, key)
+
+	return append(buf, ':')
+}
+
+// appendJSONValue adds the value encoded by the same rules as used by the
+// standard library's JSON handler.
+func appendJSONValue(buf []byte, v slog.Value) []byte {
+	switch v.Kind() {
+	case slog.KindString:
+		return appendJSONString(buf, v.String())
+	case slog.KindInt64:
+		return strconv.AppendInt(buf, v.Int64(), 10)
+	case slog.KindUint64:
+		return strconv.AppendUint(buf, v.Uint64(), 10)
+	case slog.KindFloat64:
+		// json.Marshal picks the shortest representation and fails on NaN and
+		// infinity exactly like the JSON handler does.
+		return appendJSONMarshal(buf, v.Float64())
+	case slog.KindBool:
+		return strconv.AppendBool(buf, v.Bool())
+	case slog.KindDuration:
+		return strconv.AppendInt(buf, int64(v.Duration()), 10)
+	case slog.KindTime:
+		buf = append(buf, '"')
+		buf = v.Time().AppendFormat(buf, time.RFC3339Nano)
+		return append(buf, '"')
+	default:
+		return appendJSONAny(buf, v.Any())
+	}
+}
+
+// appendJSONAny adds a value of kind Any. Errors that can't marshal themselves
+// are written as their message, while panics of the value's methods are
+// written as !PANIC: values.
+func appendJSONAny(buf []byte, val any) (b []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			b = appendJSONString(buf, panicValue(r, val))
+		}
+	}()
+
+	if err, ok := val.(error); ok {
+		if _, ok := val.(json.Marshaler); !ok {
+			return appendJSONString(buf, err.Error())
+		}
+	}
+
+	return appendJSONMarshal(buf, val)
+}
+
+// appendJSONMarshal adds the value marshalled into JSON without escaping HTML
+// characters. Marshalling errors are written as strings.
+func appendJSONMarshal(buf []byte, v any) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return appendJSONString(buf, "!ERROR:"+err.Error())
+	}
+
+	return append(buf, bytes.TrimRight(b.Bytes(), "\n")...)
+}
+
+// appendJSONString adds the quoted and escaped string. Invalid UTF-8 is
+// replaced by the replacement character, while HTML characters are left as
+// they are.
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+
+		// U+2028 and U+2029 are valid JSON but break JavaScript parsers, so
+		// they are escaped by encoding/json as well.
+		if c == '\u2028' || c == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[c&0xf])
+			i += size
+			start = i
+			continue
+		}
+
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+
+	return append(buf, '"')
+}
@@ -0,0 +1,163 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type jsonError struct{}
+
+func (jsonError) Error() string { return "ignored" }
+
+func (jsonError) MarshalJSON() ([]byte, error) { return []byte(`{"code":42}`), nil }
+
+func TestCEEFormat(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	opts := formatOptions{
+		Hostname: "localhost",
+		Facility: User,
+		Tag:      "test",
+	}
+
+	r := slog.NewRecord(testTime, slog.LevelWarn, "a message", 0)
+	r.AddAttrs(slog.Int("a", 1))
+
+	want := []byte("<12>Jan  2 03:04:05 localhost test[" + pid + `]: @cee: {"level":"WARN","msg":"a message","a":1}` + "\n")
+
+	buf := make([]byte, 0, 1024)
+	buf = ceeFormat(context.Background(), buf, r, opts)
+	if !bytes.Equal(buf, want) {
+		t.Errorf("ceeFormat(ctx, buf, %v, %v) = %s; want %s", r, opts, buf, want)
+	}
+}
+
+func TestAppendCEE(t *testing.T) {
+	testCases := [...]struct {
+		name  string
+		attrs []slog.Attr
+		want  string
+	}{
+		{
+			name: "NoAttrs",
+			want: `{"level":"INFO","msg":"a message"}`,
+		},
+		{
+			name: "Scalars",
+			attrs: []slog.Attr{
+				slog.String("s", "<qu\"o>\n\x01\u2028"),
+				slog.Int("i", -1),
+				slog.Uint64("u", 2),
+				slog.Float64("f", 1.5),
+				slog.Bool("b", true),
+				slog.Duration("d", time.Second),
+				slog.Time("t", testTime),
+			},
+			want: `{"level":"INFO","msg":"a message","s":"<qu\"o>\n\u0001\u2028","i":-1,"u":2,"f":1.5,"b":true,"d":1000000000,"t":"2000-01-02T03:04:05Z"}`,
+		},
+		{
+			name: "Any",
+			attrs: []slog.Attr{
+				slog.Any("err", errors.New("failed")),
+				slog.Any("jerr", jsonError{}),
+				slog.Any("x", struct{ A, b int }{A: 1, b: 2}),
+				slog.Any("nil", nil),
+				slog.Any("nan", math.NaN()),
+			},
+			want: `{"level":"INFO","msg":"a message","err":"failed","jerr":{"code":42},"x":{"A":1},"nil":null,"nan":"!ERROR:json: unsupported value: NaN"}`,
+		},
+		{
+			name: "Groups",
+			attrs: []slog.Attr{
+				slog.Group("g", slog.Int("a", 1), slog.Group("h", slog.Int("b", 2))),
+				slog.Group("", slog.Int("inline", 3)),
+				slog.Group("empty"),
+				{},
+			},
+			want: `{"level":"INFO","msg":"a message","g":{"a":1,"h":{"b":2}},"inline":3}`,
+		},
+		{
+			name:  "InvalidUTF8",
+			attrs: []slog.Attr{slog.String("s", "a\xffb")},
+			want:  "{\"level\":\"INFO\",\"msg\":\"a message\",\"s\":\"a\ufffdb\"}",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attrs...)
+
+			if got := string(appendCEE(nil, r, formatOptions{})); got != tc.want {
+				t.Errorf("appendCEE(nil, %v, opts) = %s; want %s", r, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAppendCEE_JSONHandler(t *testing.T) {
+	testCases := [...]struct {
+		name   string
+		derive func(slog.Handler) slog.Handler
+		attrs  []slog.Attr
+	}{
+		{
+			name: "Attrs",
+			derive: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Int("a", 1)})
+			},
+			attrs: []slog.Attr{slog.Int("b", 2)},
+		},
+		{
+			name: "Groups",
+			derive: func(h slog.Handler) slog.Handler {
+				h = h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g")
+				return h.WithAttrs([]slog.Attr{slog.Int("b", 2)}).WithGroup("h")
+			},
+			attrs: []slog.Attr{slog.Int("c", 3)},
+		},
+		{
+			name: "EmptyGroups",
+			derive: func(h slog.Handler) slog.Handler {
+				return h.WithAttrs([]slog.Attr{slog.Int("a", 1)}).WithGroup("g").WithGroup("h")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			var want bytes.Buffer
+			jh := tc.derive(slog.NewJSONHandler(&want, &slog.HandlerOptions{
+				ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+					if len(groups) == 0 && a.Key == slog.TimeKey {
+						return slog.Attr{}
+					}
+					return a
+				},
+			}))
+			sh := tc.derive(newTestHandler(t, &Options{Format: FormatCEE})).(*SyslogHandler)
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attrs...)
+
+			if err := jh.Handle(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+
+			got := appendCEE(nil, r, formatOptions{Groups: sh.goas})
+			if !bytes.Equal(got, bytes.TrimSuffix(want.Bytes(), []byte{'\n'})) {
+				t.Errorf("appendCEE(nil, %v, opts) = %s; want %s", r, got, want.Bytes())
+			}
+		})
+	}
+}
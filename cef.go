@@ -0,0 +1,143 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// extField is an attribute flattened into a key and value of the CEF and LEEF
+// extensions.
+type extField struct {
+	key, value string
+}
+
+// eventSeverity turns slog level into the 0 to 10 scale of CEF and LEEF
+// severities. Levels up to an error are scaled like their syslog priorities,
+// while the levels above it, matching the critical, alert and emergency
+// severities, take the top of the scale.
+func eventSeverity(l slog.Level) int64 {
+	switch {
+	case l <= slog.LevelDebug:
+		return 1
+	case l <= slog.LevelInfo:
+		return 3
+	case l <= slog.LevelWarn:
+		return 5
+	case l <= slog.LevelError:
+		return 7
+	case l <= slog.LevelError+4:
+		return 8
+	case l <= slog.LevelError+8:
+		return 9
+	default:
+		return 10
+	}
+}
+
+// cefFormat outputs a message in the BSD syslog format with the message in the
+// Common Event Format. The signature ID is taken from the [EventIDKey]
+// attribute, the name is the record's message and the severity is derived from
+// the level. All other attributes are written as extensions.
+func cefFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	eventID, fields := extFields(r, opts)
+
+	buf = appendRFC3164Header(buf, r, opts)
+	buf = append(buf, "CEF:0|"...)
+	for _, s := range [...]string{opts.Vendor, opts.Product, opts.Version, eventID, r.Message} {
+		buf = append(buf, headerEscape.Replace(s)...)
+		buf = append(buf, '|')
+	}
+	buf = strconv.AppendInt(buf, eventSeverity(r.Level), 10)
+	buf = append(buf, '|')
+
+	for i, f := range fields {
+		if i > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = appendExtKey(buf, f.key)
+		buf = append(buf, '=')
+		buf = append(buf, cefEscape.Replace(f.value)...)
+	}
+
+	return append(buf, '\n')
+}
+
+// leefFormat outputs a message in the BSD syslog format with the message in the
+// Log Event Extended Format version 2.0. The event ID is taken from the
+// [EventIDKey] attribute or the record's message, in which case the message is
+// written as the msg attribute. The severity derived from the level is written
+// as the sev attribute and all attributes are delimited by tabs.
+func leefFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	eventID, fields := extFields(r, opts)
+
+	buf = appendRFC3164Header(buf, r, opts)
+	buf = append(buf, "LEEF:2.0|"...)
+	for _, s := range [...]string{opts.Vendor, opts.Product, opts.Version, eventID} {
+		buf = append(buf, headerEscape.Replace(s)...)
+		buf = append(buf, '|')
+	}
+
+	buf = append(buf, "sev="...)
+	buf = strconv.AppendInt(buf, eventSeverity(r.Level), 10)
+	if eventID != r.Message && r.Message != "" {
+		buf = append(buf, "\tmsg="...)
+		buf = append(buf, leefEscape.Replace(r.Message)...)
+	}
+
+	for _, f := range fields {
+		buf = append(buf, '\t')
+		buf = appendExtKey(buf, f.key)
+		buf = append(buf, '=')
+		buf = append(buf, leefEscape.Replace(f.value)...)
+	}
+
+	return append(buf, '\n')
+}
+
+// extFields flattens the source and all the attributes into extension fields
+// with their keys mapped by the extension keys. The event ID is taken out of
+// the attributes and defaults to the record's message.
+func extFields(r slog.Record, opts formatOptions) (eventID string, fields []extField) {
+	eventID = r.Message
+	flattenAttrs(r, opts, false, func(key string, v slog.Value) {
+		v = attrTime(v, opts)
+		if key == EventIDKey {
+			eventID = extValue(v)
+			return
+		}
+
+		if k, ok := opts.ExtKeys[key]; ok {
+			key = k
+		}
+		fields = append(fields, extField{key, extValue(v)})
+	})
+
+	return eventID, fields
+}
+
+// extValue returns the value as a string following the same rules as used by
+// the attributes of the other formats.
+func extValue(v slog.Value) string {
+	switch v.Kind() {
+	case slog.KindTime:
+		return v.Time().Format(time.RFC3339Nano)
+	case slog.KindAny:
+		return anyString(v.Any())
+	default:
+		return v.String()
+	}
+}
+
+// appendExtKey adds the extension key replacing the characters that can't be
+// part of it by underscores.
+func appendExtKey(buf []byte, key string) []byte {
+	return append(buf, strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, key)...)
+}
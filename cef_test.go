@@ -0,0 +1,160 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestCEFFormat(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	header := "<11>Jan  2 03:04:05 localhost test[" + pid + "]: "
+	testCases := [...]struct {
+		name   string
+		level  slog.Level
+		msg    string
+		groups []groupOrAttrs
+		attrs  []slog.Attr
+		want   []byte
+	}{
+		{
+			name:  "NoAttrs",
+			level: slog.LevelInfo,
+			msg:   "a message",
+			want:  []byte("<14>Jan  2 03:04:05 localhost test[" + pid + "]: CEF:0|Acme|App|1.0|a message|a message|3|\n"),
+		},
+		{
+			name:  "EventID",
+			level: slog.LevelError,
+			msg:   "login failed",
+			attrs: []slog.Attr{slog.String(EventIDKey, "100"), slog.String("user", "bob")},
+			want:  []byte(header + "CEF:0|Acme|App|1.0|100|login failed|7|suser=bob\n"),
+		},
+		{
+			name:  "Escaping",
+			level: slog.LevelError,
+			msg:   `a|b\c` + "\n",
+			attrs: []slog.Attr{slog.String("x y", "a=b\\c\nd")},
+			want:  []byte(header + `CEF:0|Acme|App|1.0|a\|b\\c |a\|b\\c |7|x_y=a\=b\\c\nd` + "\n"),
+		},
+		{
+			name:   "Groups",
+			level:  slog.LevelError,
+			msg:    "a message",
+			groups: []groupOrAttrs{{attrs: []slog.Attr{slog.String("ip", "10.0.0.1")}}, {group: "req"}},
+			attrs:  []slog.Attr{slog.Group("peer", slog.String("ip", "10.0.0.2")), slog.Int("n", 1)},
+			want:   []byte(header + "CEF:0|Acme|App|1.0|a message|a message|7|src=10.0.0.1 dst=10.0.0.2 req.n=1\n"),
+		},
+		{
+			name:  "Critical",
+			level: slog.Level(12),
+			msg:   "disk failed",
+			want:  []byte(header + "CEF:0|Acme|App|1.0|disk failed|disk failed|8|\n"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := formatOptions{
+				Hostname: "localhost",
+				Facility: User,
+				Tag:      "test",
+				Groups:   tc.groups,
+				Vendor:   "Acme",
+				Product:  "App",
+				Version:  "1.0",
+				ExtKeys:  map[string]string{"user": "suser", "ip": "src", "req.peer.ip": "dst"},
+			}
+
+			r := slog.NewRecord(testTime, tc.level, tc.msg, 0)
+			r.AddAttrs(tc.attrs...)
+
+			buf := make([]byte, 0, 1024)
+			buf = cefFormat(context.Background(), buf, r, opts)
+			if !bytes.Equal(buf, tc.want) {
+				t.Errorf("cefFormat(ctx, buf, %v, %v) = %s; want %s", r, opts, buf, tc.want)
+			}
+		})
+	}
+}
+
+func TestEventSeverity(t *testing.T) {
+	testCases := [...]struct {
+		level slog.Level
+		want  int64
+	}{
+		{slog.LevelDebug - 4, 1},
+		{slog.LevelDebug, 1},
+		{slog.LevelInfo, 3},
+		{slog.LevelInfo + 2, 5},
+		{slog.LevelWarn, 5},
+		{slog.LevelError, 7},
+		{slog.LevelError + 4, 8},
+		{slog.LevelError + 8, 9},
+		{slog.LevelError + 12, 10},
+		{slog.LevelError + 100, 10},
+	}
+
+	for _, tc := range testCases {
+		if got := eventSeverity(tc.level); got != tc.want {
+			t.Errorf("eventSeverity(%v) = %d; want %d", tc.level, got, tc.want)
+		}
+	}
+}
+
+func TestLEEFFormat(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	header := "<12>Jan  2 03:04:05 localhost test[" + pid + "]: "
+	testCases := [...]struct {
+		name  string
+		attrs []slog.Attr
+		want  []byte
+	}{
+		{
+			name: "NoAttrs",
+			want: []byte(header + "LEEF:2.0|Acme|App|1.0|a message|sev=5\n"),
+		},
+		{
+			name:  "EventID",
+			attrs: []slog.Attr{slog.String(EventIDKey, "100"), slog.String("user", "bob")},
+			want:  []byte(header + "LEEF:2.0|Acme|App|1.0|100|sev=5\tmsg=a message\tusrName=bob\n"),
+		},
+		{
+			name:  "Escaping",
+			attrs: []slog.Attr{slog.String("x=y", "a\tb\nc")},
+			want:  []byte(header + "LEEF:2.0|Acme|App|1.0|a message|sev=5\tx_y=a\\tb\\nc\n"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := formatOptions{
+				Hostname: "localhost",
+				Facility: User,
+				Tag:      "test",
+				Vendor:   "Acme",
+				Product:  "App",
+				Version:  "1.0",
+				ExtKeys:  map[string]string{"user": "usrName"},
+			}
+
+			r := slog.NewRecord(testTime, slog.LevelWarn, "a message", 0)
+			r.AddAttrs(tc.attrs...)
+
+			buf := make([]byte, 0, 1024)
+			buf = leefFormat(context.Background(), buf, r, opts)
+			if !bytes.Equal(buf, tc.want) {
+				t.Errorf("leefFormat(ctx, buf, %v, %v) = %s; want %s", r, opts, buf, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Command slog-logger makes entries in the system log. It mimics the logger
+// command from util-linux, but writes messages through the slog syslog handler,
+// so they look exactly the same as the ones written by Go services using it.
+//
+// Usage:
+//
+//	slog-logger [options] [message...]
+//
+// The message is taken from the arguments or, if there are none, each line read
+// from the standard input is logged separately. The options are:
+//
+//	-p, --priority facility.level
+//		priority of the message (default user.notice)
+//	-t, --tag tag
+//		tag of the message (default is the current user's name)
+//	-n, --server host
+//		write to a remote syslog server instead of /dev/log
+//	-P, --port port
+//		port of the remote syslog server (default 514)
+//	-T, --tcp
+//		use TCP only to connect to the remote syslog server
+//	-d, --udp
+//		use UDP only to connect to the remote syslog server (default)
+//	-u, --socket path
+//		write to a UNIX datagram socket instead of /dev/log
+//	--rfc3164
+//		use the BSD syslog format
+//	--rfc5424
+//		use the syslog protocol format
+//	--sd-id id
+//		add a structured data element with the given ID
+//	--sd-param name=value
+//		add a parameter to the last structured data element
+//	-e key=value
+//		add an attribute to the message
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+	"github.com/mocheryl/slog-syslog/internal/cliutil"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stderr))
+}
+
+// run runs the command and returns its exit code.
+func run(args []string, stdin io.Reader, stderr io.Writer) int {
+	fs := flag.NewFlagSet("slog-logger", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		priority = "user.notice"
+		tag      string
+		server   string
+		port     = "514"
+		tcp      bool
+		udp      bool
+		socket   string
+		rfc3164  bool
+		rfc5424  bool
+		sd       sdElements
+		attrs    attrList
+	)
+	fs.StringVar(&priority, "p", priority, "priority of the message as `facility.level`")
+	fs.StringVar(&priority, "priority", priority, "priority of the message as `facility.level`")
+	fs.StringVar(&tag, "t", tag, "`tag` of the message")
+	fs.StringVar(&tag, "tag", tag, "`tag` of the message")
+	fs.StringVar(&server, "n", server, "remote syslog server `host`")
+	fs.StringVar(&server, "server", server, "remote syslog server `host`")
+	fs.StringVar(&port, "P", port, "remote syslog server `port`")
+	fs.StringVar(&port, "port", port, "remote syslog server `port`")
+	fs.BoolVar(&tcp, "T", tcp, "use TCP to connect to the remote syslog server")
+	fs.BoolVar(&tcp, "tcp", tcp, "use TCP to connect to the remote syslog server")
+	fs.BoolVar(&udp, "d", udp, "use UDP to connect to the remote syslog server")
+	fs.BoolVar(&udp, "udp", udp, "use UDP to connect to the remote syslog server")
+	fs.StringVar(&socket, "u", socket, "write to the UNIX datagram socket at `path`")
+	fs.StringVar(&socket, "socket", socket, "write to the UNIX datagram socket at `path`")
+	fs.BoolVar(&rfc3164, "rfc3164", rfc3164, "use the BSD syslog format")
+	fs.BoolVar(&rfc5424, "rfc5424", rfc5424, "use the syslog protocol format")
+	fs.Var((*sdID)(&sd), "sd-id", "add a structured data element with the given `id`")
+	fs.Var((*sdParam)(&sd), "sd-param", "add a `name=value` parameter to the last structured data element")
+	fs.Var(&attrs, "e", "add a `key=value` attribute to the message")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	facility, severity, err := cliutil.ParsePriority(priority)
+	if err != nil {
+		fmt.Fprintf(stderr, "slog-logger: %s\n", err)
+		return 2
+	}
+
+	opts := &slogsyslog.Options{
+		Level:    slogsyslog.Debug,
+		Facility: facility,
+		Tag:      tag,
+		Severity: func(slog.Record) slogsyslog.Severity { return severity },
+	}
+	if opts.Tag == "" {
+		opts.Tag = defaultTag()
+	}
+
+	switch {
+	case server != "":
+		opts.Network = "udp"
+		if tcp && !udp {
+			opts.Network = "tcp"
+		}
+		opts.Address = net.JoinHostPort(server, port)
+	case socket != "":
+		opts.Network = "unixgram"
+		opts.Address = socket
+	}
+
+	switch {
+	case rfc5424:
+		opts.Format = slogsyslog.FormatRFC5424
+	case rfc3164:
+		opts.Format = slogsyslog.FormatRFC3164
+	}
+
+	h, err := slogsyslog.New(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "slog-logger: %s\n", err)
+		return 1
+	}
+	defer h.Close()
+
+	for _, e := range sd {
+		attrs = append(attrs, slog.Any(e.ID, e))
+	}
+
+	log := func(msg string) error {
+		r := slog.NewRecord(time.Now(), severity.Level(), msg, 0)
+		r.AddAttrs(attrs...)
+		return h.Handle(context.Background(), r)
+	}
+
+	if fs.NArg() > 0 {
+		if err := log(strings.Join(fs.Args(), " ")); err != nil {
+			fmt.Fprintf(stderr, "slog-logger: %s\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	sc := bufio.NewScanner(stdin)
+	for sc.Scan() {
+		if sc.Text() == "" {
+			continue
+		}
+		if err := log(sc.Text()); err != nil {
+			fmt.Fprintf(stderr, "slog-logger: %s\n", err)
+			return 1
+		}
+	}
+	if err := sc.Err(); err != nil {
+		fmt.Fprintf(stderr, "slog-logger: %s\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+// defaultTag returns the name of the current user.
+func defaultTag() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+
+	return "slog-logger"
+}
+
+// attrList is a flag collecting key=value attributes.
+type attrList []slog.Attr
+
+func (l *attrList) String() string { return "" }
+
+func (l *attrList) Set(s string) error {
+	key, val, ok := strings.Cut(s, "=")
+	if !ok || key == "" {
+		return errors.New("attribute must be in key=value form")
+	}
+	*l = append(*l, slog.String(key, val))
+
+	return nil
+}
+
+// sdElements are structured data elements collected from flags.
+type sdElements []slogsyslog.SDElement
+
+// sdID is a flag adding a new structured data element.
+type sdID sdElements
+
+func (e *sdID) String() string { return "" }
+
+func (e *sdID) Set(s string) error {
+	if s == "" {
+		return errors.New("structured data ID must not be empty")
+	}
+	*e = append(*e, slogsyslog.SDElement{ID: s})
+
+	return nil
+}
+
+// sdParam is a flag adding a parameter to the last structured data element.
+type sdParam sdElements
+
+func (e *sdParam) String() string { return "" }
+
+func (e *sdParam) Set(s string) error {
+	if len(*e) == 0 {
+		return errors.New("structured data parameter must follow --sd-id")
+	}
+
+	name, val, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return errors.New("structured data parameter must be in name=value form")
+	}
+	if uq, err := strconv.Unquote(val); err == nil {
+		val = uq
+	}
+
+	last := &(*e)[len(*e)-1]
+	last.Params = append(last.Params, slogsyslog.SDParam{Name: name, Value: val})
+
+	return nil
+}
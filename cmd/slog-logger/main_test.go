@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+	"github.com/mocheryl/slog-syslog/slogsyslogtest"
+)
+
+func TestRun(t *testing.T) {
+	s := slogsyslogtest.NewServer(t, "udp")
+	host, port, _ := net.SplitHostPort(s.Address())
+
+	var stderr bytes.Buffer
+	args := []string{
+		"-n", host, "-P", port, "-d",
+		"-p", "local3.warning",
+		"-t", "test",
+		"--rfc5424",
+		"--sd-id", "zoo@123", "--sd-param", `tiger="hungry"`,
+		"-e", "a=1",
+		"a", "message",
+	}
+	if code := run(args, nil, &stderr); code != 0 {
+		t.Fatalf("run(%q) = %d; want 0: %s", args, code, &stderr)
+	}
+
+	m := s.Wait(1)[0]
+	if m.Err != nil {
+		t.Fatalf("Message %q: %s", m.Raw, m.Err)
+	}
+	if m.Facility != slogsyslog.Local3 || m.Severity != slogsyslog.Warning || m.AppName != "test" || m.Text != "a message" {
+		t.Errorf("Message = %s.%s %q %q; want %s.%s %q %q", m.Facility, m.Severity, m.AppName, m.Text,
+			slogsyslog.Local3, slogsyslog.Warning, "test", "a message")
+	}
+	m.AssertAttr(t, slogsyslog.DefaultSDID+".a", "1")
+	m.AssertAttr(t, "zoo@123.tiger", "hungry")
+}
+
+func TestRun_Stdin(t *testing.T) {
+	s := slogsyslogtest.NewServer(t, "tcp")
+	host, port, _ := net.SplitHostPort(s.Address())
+
+	var stderr bytes.Buffer
+	args := []string{"-n", host, "-P", port, "-T", "--rfc3164"}
+	if code := run(args, strings.NewReader("first\n\nsecond\n"), &stderr); code != 0 {
+		t.Fatalf("run(%q) = %d; want 0: %s", args, code, &stderr)
+	}
+
+	msgs := s.Wait(2)
+	for i, want := range [...]string{"first", "second"} {
+		if !strings.HasPrefix(msgs[i].Raw, "<13>") || msgs[i].Text != want {
+			t.Errorf("Message[%d] = %q; want %q with priority <13>", i, msgs[i].Raw, want)
+		}
+	}
+}
+
+func TestRun_Priority(t *testing.T) {
+	testCases := [...]struct {
+		priority string
+		want     string
+	}{
+		{priority: "notice", want: "<13>"},
+		{priority: "crit", want: "<10>"},
+		{priority: "alert", want: "<9>"},
+		{priority: "local0.emerg", want: "<128>"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.priority, func(t *testing.T) {
+			t.Parallel()
+
+			s := slogsyslogtest.NewServer(t, "udp")
+			host, port, _ := net.SplitHostPort(s.Address())
+
+			var stderr bytes.Buffer
+			args := []string{"-n", host, "-P", port, "-d", "--rfc5424", "-p", tc.priority, "a message"}
+			if code := run(args, nil, &stderr); code != 0 {
+				t.Fatalf("run(%q) = %d; want 0: %s", args, code, &stderr)
+			}
+
+			if m := s.Wait(1)[0]; !strings.HasPrefix(m.Raw, tc.want) {
+				t.Errorf("Message = %q; want priority %s", m.Raw, tc.want)
+			}
+		})
+	}
+}
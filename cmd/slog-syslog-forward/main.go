@@ -0,0 +1,209 @@
+// Command slog-syslog-forward forwards logs written by the
+// [log/slog.JSONHandler] to a syslog server. Each JSON line is turned back
+// into a record with its original time, level, message, source and attributes
+// and written through the slog syslog handler.
+//
+// Usage:
+//
+//	slog-syslog-forward [options]
+//
+// Lines are read from the standard input unless a file to follow is given.
+// Lines that aren't valid JSON objects are forwarded as they are, as are the
+// first MiB of longer lines read from the standard input. The options are:
+//
+//	-f path
+//		follow the file at path like tail -F, surviving its rotation
+//	-from-start
+//		forward the followed file from its beginning instead of its end
+//	-poll interval
+//		interval at which the followed file is checked (default 250ms)
+//	-network network
+//		network of the syslog server (default unixgram)
+//	-address address
+//		address of the syslog server (default /dev/log)
+//	-facility facility
+//		facility of the messages (default user)
+//	-tag tag
+//		tag of the messages (default slog-syslog-forward)
+//	-format format
+//		one of auto, go, local, rfc3164, rfc5424, cee, cef, leef or gelf
+//		(default auto)
+//	-level level
+//		minimum level of the forwarded records (default debug)
+//	-raw-level level
+//		level of the lines that aren't valid JSON objects (default info)
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdin, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command and returns its exit code.
+func run(ctx context.Context, args []string, stdin io.Reader, stderr io.Writer) int {
+	fs := flag.NewFlagSet("slog-syslog-forward", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	var (
+		path      = fs.String("f", "", "follow the file at `path`")
+		fromStart = fs.Bool("from-start", false, "forward the followed file from its beginning")
+		poll      = fs.Duration("poll", 250*time.Millisecond, "`interval` at which the followed file is checked")
+		network   = fs.String("network", "", "`network` of the syslog server")
+		address   = fs.String("address", "", "`address` of the syslog server")
+		facility  = fs.String("facility", "user", "`facility` of the messages")
+		tag       = fs.String("tag", "slog-syslog-forward", "`tag` of the messages")
+		format    = fs.String("format", "auto", "`format` of the messages")
+		level     = fs.String("level", "debug", "minimum `level` of the forwarded records")
+		rawLevel  = fs.String("raw-level", "info", "`level` of the lines that aren't valid JSON objects")
+	)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := &slogsyslog.Options{
+		Network: *network,
+		Address: *address,
+		Tag:     *tag,
+	}
+
+	var (
+		minLevel, rawLvl slog.Level
+		err              error
+	)
+	usage := func(err error) int {
+		fmt.Fprintf(stderr, "slog-syslog-forward: %s\n", err)
+		return 2
+	}
+	if opts.Facility, err = slogsyslog.ParseFacility(*facility); err != nil {
+		return usage(err)
+	}
+	if opts.Format, err = slogsyslog.ParseFormat(*format); err != nil {
+		return usage(err)
+	}
+	if err = minLevel.UnmarshalText([]byte(*level)); err != nil {
+		return usage(err)
+	}
+	if err = rawLvl.UnmarshalText([]byte(*rawLevel)); err != nil {
+		return usage(err)
+	}
+	opts.Level = minLevel
+
+	h, err := slogsyslog.New(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "slog-syslog-forward: %s\n", err)
+		return 1
+	}
+	defer h.Close()
+
+	next, closeInput, err := input(ctx, *path, *fromStart, *poll, stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "slog-syslog-forward: %s\n", err)
+		return 1
+	}
+	defer closeInput()
+
+	for {
+		line, err := next()
+		long := errors.Is(err, errLongLine)
+		if err != nil && !long {
+			if errors.Is(err, io.EOF) || errors.Is(err, context.Canceled) {
+				return 0
+			}
+			fmt.Fprintf(stderr, "slog-syslog-forward: %s\n", err)
+			return 1
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		r, err := parseRecord(line)
+		if err != nil || long {
+			r = slog.NewRecord(time.Now(), rawLvl, string(line), 0)
+		}
+		if !h.Enabled(ctx, r.Level) {
+			continue
+		}
+
+		// Just like a logger, we keep going if the syslog server is
+		// unavailable, since the handler reconnects on the next record.
+		if err := h.Handle(ctx, r); err != nil {
+			fmt.Fprintf(stderr, "slog-syslog-forward: %s\n", err)
+		}
+	}
+}
+
+// input returns a function reading lines either from the followed file or the
+// standard input.
+func input(ctx context.Context, path string, fromStart bool, poll time.Duration, stdin io.Reader) (next func() ([]byte, error), closeFn func() error, err error) {
+	if path != "" {
+		t, err := newTailer(path, fromStart, poll)
+		if err != nil {
+			return nil, nil, err
+		}
+		return func() ([]byte, error) { return t.next(ctx) }, t.close, nil
+	}
+
+	return lineReader(stdin, maxLineSize), func() error { return nil }, nil
+}
+
+// maxLineSize is the maximum size of the lines read from the standard input.
+const maxLineSize = 1 << 20
+
+// errLongLine is returned along with the beginning of a line that is too long.
+var errLongLine = errors.New("line too long")
+
+// lineReader returns a function reading lines without the trailing new line
+// from r. Lines longer than max bytes are truncated and returned with
+// errLongLine.
+func lineReader(r io.Reader, max int) func() ([]byte, error) {
+	br := bufio.NewReader(r)
+	var line []byte
+
+	return func() ([]byte, error) {
+		line = line[:0]
+		long := false
+		for {
+			frag, err := br.ReadSlice('\n')
+			if err == nil {
+				frag = frag[:len(frag)-1]
+			}
+			if n := max - len(line); len(frag) > n {
+				frag, long = frag[:n], true
+			}
+			line = append(line, frag...)
+
+			switch {
+			case errors.Is(err, bufio.ErrBufferFull):
+				continue
+			case errors.Is(err, io.EOF) && (len(line) > 0 || long):
+				// The last line doesn't end with a new line.
+			case err != nil:
+				return nil, err
+			}
+			line = bytes.TrimSuffix(line, []byte{'\r'})
+			if long {
+				return line, errLongLine
+			}
+			return line, nil
+		}
+	}
+}
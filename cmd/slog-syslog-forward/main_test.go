@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+	"github.com/mocheryl/slog-syslog/slogsyslogtest"
+)
+
+func TestRun(t *testing.T) {
+	s := slogsyslogtest.NewServer(t, "unixgram")
+
+	var in bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&in, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	l.Warn("structured", "user", "gopher", slog.Group("req", "id", 7))
+	in.WriteString("not json at all\n")
+	l.Debug("filtered")
+	l.Info("last")
+
+	var stderr bytes.Buffer
+	args := []string{
+		"-network", s.Network(), "-address", s.Address(),
+		"-facility", "local3", "-tag", "test", "-format", "rfc5424", "-level", "info",
+	}
+	if code := run(context.Background(), args, strings.NewReader(in.String()), &stderr); code != 0 {
+		t.Fatalf("run(%q) = %d; want 0: %s", args, code, &stderr)
+	}
+
+	msgs := s.Wait(3)
+	if len(msgs) != 3 || msgs[2].Text != "last" {
+		t.Fatalf("received %d messages ending with %q; want 3 ending with %q", len(msgs), msgs[len(msgs)-1].Text, "last")
+	}
+
+	m := msgs[0]
+	if m.Facility != slogsyslog.Local3 || m.Severity != slogsyslog.Warning || m.AppName != "test" || m.Text != "structured" {
+		t.Errorf("Message = %s.%s %q %q; want %s.%s %q %q", m.Facility, m.Severity, m.AppName, m.Text,
+			slogsyslog.Local3, slogsyslog.Warning, "test", "structured")
+	}
+	m.AssertAttr(t, slogsyslog.DefaultSDID+".user", "gopher")
+	m.AssertAttr(t, slogsyslog.DefaultSDID+".req.id", "7")
+
+	if m = msgs[1]; m.Severity != slogsyslog.Info || m.Text != "not json at all" {
+		t.Errorf("Message = %s %q; want %s %q", m.Severity, m.Text, slogsyslog.Info, "not json at all")
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	// The long line doesn't fit in the reader's buffer either.
+	long := strings.Repeat("x", 10000)
+	next := lineReader(strings.NewReader("short\n"+long+"\r\nfits\r\nlast"), 5000)
+
+	for _, want := range [...]struct {
+		line string
+		err  error
+	}{
+		{"short", nil},
+		{long[:5000], errLongLine},
+		{"fits", nil},
+		{"last", nil},
+		{"", io.EOF},
+	} {
+		line, err := next()
+		if string(line) != want.line || !errors.Is(err, want.err) {
+			t.Errorf("next() = %q, %v; want %q, %v", line, err, want.line, want.err)
+		}
+	}
+}
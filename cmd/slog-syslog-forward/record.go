@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+)
+
+// parseRecord reconstructs a record from a line written by the
+// [log/slog.JSONHandler]. The time, level and message are taken from their
+// standard keys, source is turned back into a [log/slog.Source] and any nested
+// object becomes a group. Keys keep their order.
+func parseRecord(line []byte) (slog.Record, error) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return slog.Record{}, err
+	}
+
+	var (
+		t     time.Time
+		level = slog.LevelInfo
+		msg   string
+		attrs []slog.Attr
+	)
+	for dec.More() {
+		key, err := decodeKey(dec)
+		if err != nil {
+			return slog.Record{}, err
+		}
+
+		v, err := decodeValue(dec)
+		if err != nil {
+			return slog.Record{}, err
+		}
+
+		switch {
+		case key == slog.TimeKey && v.Kind() == slog.KindString:
+			if t, err = time.Parse(time.RFC3339Nano, v.String()); err != nil {
+				return slog.Record{}, fmt.Errorf("invalid time: %w", err)
+			}
+		case key == slog.LevelKey && v.Kind() == slog.KindString:
+			if err := level.UnmarshalText([]byte(v.String())); err != nil {
+				return slog.Record{}, err
+			}
+		case key == slog.MessageKey && v.Kind() == slog.KindString:
+			msg = v.String()
+		case key == slog.SourceKey && v.Kind() == slog.KindGroup:
+			attrs = append(attrs, slog.Any(slog.SourceKey, groupSource(v.Group())))
+		default:
+			attrs = append(attrs, slog.Attr{Key: key, Value: v})
+		}
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return slog.Record{}, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return slog.Record{}, errors.New("unexpected data after object")
+	}
+
+	if t.IsZero() {
+		t = time.Now()
+	}
+	r := slog.NewRecord(t, level, msg, 0)
+	r.AddAttrs(attrs...)
+
+	return r, nil
+}
+
+// expectDelim reads the next token and checks whether it is the delimiter.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %s, got %v", delim, tok)
+	}
+
+	return nil
+}
+
+// decodeKey reads the next object key.
+func decodeKey(dec *json.Decoder) (string, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+
+	key, ok := tok.(string)
+	if !ok {
+		return "", fmt.Errorf("expected key, got %v", tok)
+	}
+
+	return key, nil
+}
+
+// decodeValue reads the next value. Objects become groups, while arrays are
+// kept as they are.
+func decodeValue(dec *json.Decoder) (slog.Value, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return slog.Value{}, err
+	}
+
+	switch v := tok.(type) {
+	case json.Delim:
+		if v == '[' {
+			var arr []any
+			for dec.More() {
+				var elem any
+				if err := dec.Decode(&elem); err != nil {
+					return slog.Value{}, err
+				}
+				arr = append(arr, elem)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return slog.Value{}, err
+			}
+			return slog.AnyValue(arr), nil
+		}
+
+		var attrs []slog.Attr
+		for dec.More() {
+			key, err := decodeKey(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			val, err := decodeValue(dec)
+			if err != nil {
+				return slog.Value{}, err
+			}
+			attrs = append(attrs, slog.Attr{Key: key, Value: val})
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return slog.Value{}, err
+		}
+		return slog.GroupValue(attrs...), nil
+	case string:
+		return slog.StringValue(v), nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return slog.Int64Value(i), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return slog.Value{}, err
+		}
+		return slog.Float64Value(f), nil
+	case bool:
+		return slog.BoolValue(v), nil
+	default:
+		return slog.AnyValue(nil), nil
+	}
+}
+
+// groupSource turns the source group written by the JSON handler back into a
+// source.
+func groupSource(attrs []slog.Attr) *slog.Source {
+	src := &slog.Source{}
+	for _, a := range attrs {
+		switch a.Key {
+		case "function":
+			src.Function = a.Value.String()
+		case "file":
+			src.File = a.Value.String()
+		case "line":
+			if a.Value.Kind() == slog.KindInt64 {
+				src.Line = int(a.Value.Int64())
+			}
+		}
+	}
+
+	return src
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRecord(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelDebug}))
+	l.With("a", 1).WithGroup("g").Log(context.Background(), slog.LevelWarn+1, "a message",
+		"b", "two", "c", 1.5, "d", true, "e", nil, "f", []int{1, 2}, slog.Group("h", "i", errors.New("oops")))
+
+	r, err := parseRecord(bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}))
+	if err != nil {
+		t.Fatalf("parseRecord(%s) = %v; want nil", &buf, err)
+	}
+	if r.Level != slog.LevelWarn+1 || r.Message != "a message" || time.Since(r.Time) > time.Minute {
+		t.Errorf("parseRecord(%s) = %v %v %q; want now %v %q", &buf, r.Time, r.Level, r.Message, slog.LevelWarn+1, "a message")
+	}
+
+	var got []string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == slog.SourceKey {
+			src, ok := a.Value.Any().(*slog.Source)
+			if !ok || !strings.HasSuffix(src.File, "record_test.go") || src.Line == 0 {
+				t.Errorf("parseRecord(%s) source = %v; want *slog.Source", &buf, a.Value)
+			}
+			return true
+		}
+		got = append(got, a.String())
+		return true
+	})
+	want := "a=1 g=[b=two c=1.5 d=true e=<nil> f=[1 2] h=[i=oops]]"
+	if strings.Join(got, " ") != want {
+		t.Errorf("parseRecord(%s) attributes = %s; want %s", &buf, strings.Join(got, " "), want)
+	}
+}
+
+func TestParseRecord_Malformed(t *testing.T) {
+	for _, line := range [...]string{
+		"plain text",
+		`["array"]`,
+		`{"msg":"unterminated"`,
+		`{"msg":"trailing"} garbage`,
+		`{"time":"yesterday","msg":"bad time"}`,
+		`{"level":"LOUD","msg":"bad level"}`,
+	} {
+		if _, err := parseRecord([]byte(line)); err == nil {
+			t.Errorf("parseRecord(%s) = <nil>; want error", line)
+		}
+	}
+}
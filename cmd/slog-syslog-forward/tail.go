@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"time"
+)
+
+// tailer follows a file just like tail -F does. It reopens the file when it is
+// rotated and starts from the beginning when it is truncated.
+type tailer struct {
+	// path of the followed file.
+	path string
+
+	// poll is the interval at which the file is checked for changes.
+	poll time.Duration
+
+	// f is the currently opened file.
+	f *os.File
+
+	// r reads from the file.
+	r *bufio.Reader
+
+	// offset is the current read position in the file.
+	offset int64
+
+	// partial is an incomplete line read so far.
+	partial []byte
+
+	// pending are complete lines read from a rotated file.
+	pending [][]byte
+}
+
+// newTailer opens the file for following. Unless fromStart is set, only lines
+// appended from now on are returned.
+func newTailer(path string, fromStart bool, poll time.Duration) (*tailer, error) {
+	t := &tailer{path: path, poll: poll}
+	if err := t.open(); err != nil {
+		return nil, err
+	}
+
+	if !fromStart {
+		off, err := t.f.Seek(0, io.SeekEnd)
+		if err != nil {
+			t.f.Close()
+			return nil, err
+		}
+		t.offset = off
+		t.r.Reset(t.f)
+	}
+
+	return t, nil
+}
+
+// next returns the next line without the trailing new line. It blocks until
+// one is available or the context is done.
+func (t *tailer) next(ctx context.Context) ([]byte, error) {
+	for {
+		if len(t.pending) > 0 {
+			line := t.pending[0]
+			t.pending = t.pending[1:]
+			return line, nil
+		}
+
+		line, err := t.r.ReadBytes('\n')
+		t.offset += int64(len(line))
+		if err == nil {
+			line = append(t.partial, line[:len(line)-1]...)
+			t.partial = nil
+			return line, nil
+		}
+		if err != io.EOF {
+			return nil, err
+		}
+		t.partial = append(t.partial, line...)
+
+		if err := t.check(); err != nil {
+			return nil, err
+		}
+		if len(t.pending) > 0 {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(t.poll):
+		}
+	}
+}
+
+// close closes the followed file.
+func (t *tailer) close() error { return t.f.Close() }
+
+// open opens the file at the path and starts reading from its beginning.
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+
+	t.f = f
+	t.r = bufio.NewReader(f)
+	t.offset = 0
+
+	return nil
+}
+
+// check reopens the file if it was rotated or rewinds it if it was truncated.
+// Lines left in a rotated file are queued as pending.
+func (t *tailer) check() error {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		// The file is probably being rotated, so we wait for the new one.
+		return nil
+	}
+
+	cur, err := t.f.Stat()
+	if err != nil {
+		return err
+	}
+
+	if !os.SameFile(fi, cur) {
+		// Whatever was written to the old file before it was rotated must not
+		// be lost.
+		rest, err := io.ReadAll(t.r)
+		if err != nil {
+			return err
+		}
+		rest = append(t.partial, rest...)
+		t.partial = nil
+		for len(rest) > 0 {
+			line, tail, _ := bytes.Cut(rest, []byte{'\n'})
+			t.pending = append(t.pending, line)
+			rest = tail
+		}
+
+		t.f.Close()
+		if err := t.open(); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if fi.Size() < t.offset {
+		if _, err := t.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.r.Reset(t.f)
+		t.offset = 0
+		t.partial = nil
+	}
+
+	return nil
+}
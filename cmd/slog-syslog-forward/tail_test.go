@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTailer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tl, err := newTailer(path, false, time.Millisecond)
+	if err != nil {
+		t.Fatalf("newTailer() = %v; want nil", err)
+	}
+	defer tl.close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	expect := func(want string) {
+		t.Helper()
+
+		line, err := tl.next(ctx)
+		if err != nil {
+			t.Fatalf("tailer.next() = %v; want nil", err)
+		}
+		if string(line) != want {
+			t.Fatalf("tailer.next() = %q; want %q", line, want)
+		}
+	}
+	appendFile := func(s string) {
+		t.Helper()
+
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	appendFile("first\nsec")
+	expect("first")
+	appendFile("ond\n")
+	expect("second")
+
+	// Rotation keeps the lines written to the old file before it was moved.
+	appendFile("last in old\nunterminated")
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	appendFile("first in new\n")
+	expect("last in old")
+	expect("unterminated")
+	expect("first in new")
+
+	// Truncation starts from the beginning.
+	if err := os.Truncate(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	appendFile("after\n")
+	expect("after")
+
+	cancel()
+	if _, err := tl.next(ctx); err != context.Canceled {
+		t.Errorf("tailer.next() = %v; want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,513 @@
+package slogsyslog
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// writer is a connection to a syslog server shared among the handler and all
+// of the handlers derived from it.
+type writer struct {
+	// mu protects the connection.
+	mu sync.Mutex
+
+	// network protocol to use when connecting to a syslog server.
+	network string
+
+	// address of the syslog server.
+	address string
+
+	// tlsConfig is the TLS configuration used when connecting to a syslog
+	// server. TLS is not used when nil.
+	tlsConfig *tls.Config
+
+	// dialTimeout is duration after which connecting to a syslog server
+	// timeouts.
+	dialTimeout time.Duration
+
+	// writeTimeout is duration after which writing to a syslog server timeouts.
+	writeTimeout time.Duration
+
+	// octetCounting indicates whether messages are prefixed with their length
+	// as described in RFC 6587.
+	octetCounting bool
+
+	// gelf indicates whether GELF messages are written, which are chunked on
+	// datagrams and delimited by NUL bytes on streams.
+	gelf bool
+
+	// stream indicates whether the network is stream oriented.
+	stream bool
+
+	// chunkSize is the maximum size of GELF datagrams.
+	chunkSize int
+
+	// compression of GELF datagrams.
+	compression Compression
+
+	// conn is the syslog connection. It is nil when we are not connected.
+	conn net.Conn
+
+	// hostname is the host's name written in the message header. When
+	// hostnameIP is set, it is the IP address of the outgoing interface of
+	// the last connection, once there was one.
+	hostname   atomic.Pointer[string]
+	hostnameIP bool
+
+	// closed indicates whether the writer was closed.
+	closed bool
+
+	// stats are the counters of the handlers sharing the writer.
+	stats *stats
+
+	// hooks are passed the connections established and lost.
+	hooks *hooks
+
+	// signer signs the messages written if enabled.
+	signer *signer
+
+	// relp is the RELP session when using the relp network.
+	relp *relpClient
+
+	// spool keeps the messages that can't be written if enabled.
+	spool *spool
+}
+
+// newWriter creates a new syslog writer based on the options, which counts
+// messages written in the stats and passes connection events to the hooks.
+func newWriter(opts *Options, st *stats, hk *hooks) *writer {
+	w := &writer{
+		network:      opts.Network,
+		address:      opts.Address,
+		tlsConfig:    opts.TLSConfig,
+		dialTimeout:  opts.DialTimeout,
+		writeTimeout: opts.WriteTimeout,
+		gelf:         opts.Format == FormatGELF,
+		chunkSize:    opts.GELFChunkSize,
+		compression:  opts.GELFCompression,
+		hostnameIP:   opts.Hostname == "" && opts.HostnameMode == HostnameIP,
+		stats:        st,
+		hooks:        hk,
+	}
+	hostname := resolveHostname(opts, nil)
+	w.hostname.Store(&hostname)
+
+	switch opts.Network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		w.stream = true
+	case "relp":
+		// RELP frames carry their length.
+		w.network = "tcp"
+		w.relp = newRELPClient(opts.RELP, opts.WriteTimeout, st)
+		return w
+	}
+
+	// Unlike the BSD formats, RFC 5424 messages aren't terminated by a new line
+	// and may even contain one, so they are octet counted on streams.
+	w.octetCounting = opts.Format == FormatRFC5424 && w.stream
+
+	return w
+}
+
+// connect (re)connects to the syslog server. The caller must hold the lock.
+func (w *writer) connect() error {
+	if w.conn != nil {
+		w.conn.Close()
+		w.conn = nil
+	}
+
+	var (
+		conn net.Conn
+		err  error
+	)
+	if w.tlsConfig != nil {
+		dialer := &net.Dialer{Timeout: w.dialTimeout}
+		conn, err = tls.DialWithDialer(dialer, w.network, w.address, w.tlsConfig)
+	} else {
+		conn, err = net.DialTimeout(w.network, w.address, w.dialTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	if w.relp != nil {
+		if err := w.relp.open(conn); err != nil {
+			conn.Close()
+			return err
+		}
+	}
+	w.conn = conn
+	if ip, ok := interfaceIP(conn); ok && w.hostnameIP {
+		w.hostname.Store(&ip)
+	}
+	w.hooks.connect(conn.RemoteAddr())
+
+	return nil
+}
+
+// write sends b to the syslog server and adds it to the signature block if
+// signing.
+func (w *writer) write(b []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return net.ErrClosed
+	}
+
+	if err := w.sendOrSpool(b); err != nil {
+		return err
+	}
+	if w.signer != nil && w.signer.add(b) {
+		w.sendSignatureLocked()
+	}
+
+	return nil
+}
+
+// sendOrSpool sends b once the spool, if enabled, is replayed. If it isn't or
+// sending fails, b is appended to the spool instead. The caller must hold the
+// lock.
+func (w *writer) sendOrSpool(b []byte) error {
+	if w.spool == nil {
+		return w.send(b)
+	}
+
+	if w.replayLocked() == nil && w.send(b) == nil {
+		return nil
+	}
+
+	return w.spool.append(b)
+}
+
+// startSpool spools the messages that can't be written, replaying them in the
+// background.
+func (w *writer) startSpool(sp *spool) {
+	w.mu.Lock()
+	w.spool = sp
+	w.mu.Unlock()
+
+	sp.start(w)
+}
+
+// replay sends the spooled messages unless the writer is closed.
+func (w *writer) replay() {
+	w.mu.Lock()
+	if !w.closed {
+		w.replayLocked()
+	}
+	w.mu.Unlock()
+	w.hooks.run()
+}
+
+// replayLocked sends the spooled messages in order, returning the error of the
+// first one that couldn't be sent. The caller must hold the lock.
+func (w *writer) replayLocked() error {
+	if w.spool == nil || w.spool.empty() {
+		return nil
+	}
+	defer w.spool.saveCursor()
+
+	for {
+		b := w.spool.next()
+		if b == nil {
+			return nil
+		}
+		if err := w.send(b); err != nil {
+			return err
+		}
+		w.spool.advance()
+	}
+}
+
+// send sends b to the syslog server. Just like the syslog package from the
+// standard library, if writing fails, we reconnect and try once more. The
+// caller must hold the lock.
+func (w *writer) send(b []byte) error {
+	if w.relp != nil {
+		_, err := w.sendRELP(b)
+		return err
+	}
+
+	if w.conn != nil {
+		err := w.writeConn(b)
+		if err == nil {
+			w.written(b)
+			return nil
+		}
+		w.stats.writeError(err)
+		w.hooks.disconnect(err)
+	}
+
+	if err := w.connect(); err != nil {
+		w.stats.writeError(err)
+		return err
+	}
+	w.stats.reconnects.Add(1)
+
+	if err := w.writeConn(b); err != nil {
+		w.stats.writeError(err)
+		return err
+	}
+	w.written(b)
+
+	return nil
+}
+
+// sendRELP sends b in a RELP syslog command, returning the frame waiting for
+// its acknowledgement. If the connection was lost, writing fails or the window
+// stays full, we reconnect, resending the messages that weren't acknowledged,
+// and try once more. The caller must hold the lock.
+func (w *writer) sendRELP(b []byte) (*relpFrame, error) {
+	if w.conn != nil {
+		f, err := w.relp.send(w.conn, b)
+		if err == nil {
+			return f, nil
+		}
+		w.stats.writeError(err)
+		w.hooks.disconnect(err)
+	}
+
+	if err := w.connect(); err != nil {
+		w.stats.writeError(err)
+		return nil, err
+	}
+	w.stats.reconnects.Add(1)
+
+	f, err := w.relp.send(w.conn, b)
+	if err != nil {
+		w.stats.writeError(err)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// writeAcked is write waiting for the server to acknowledge b over RELP. If it
+// doesn't in time, we reconnect, which resends it, and wait once more before
+// giving up on it.
+func (w *writer) writeAcked(b []byte) error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return net.ErrClosed
+	}
+	// The record isn't spooled, but it follows the spooled ones.
+	err := w.replayLocked()
+	var f *relpFrame
+	if err == nil {
+		f, err = w.sendRELP(b)
+	}
+	if err == nil && w.signer != nil && w.signer.add(b) {
+		w.sendSignatureLocked()
+	}
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for retried := false; ; {
+		acked, session, err := w.relp.wait(f)
+		if acked {
+			return err
+		}
+		if !w.relp.current(session) {
+			// It was resent after another write reconnected.
+			continue
+		}
+		if retried {
+			w.stats.writeError(err)
+			w.relp.remove(f)
+			return err
+		}
+		retried = true
+
+		w.mu.Lock()
+		if w.closed {
+			w.mu.Unlock()
+			return net.ErrClosed
+		}
+		if !w.relp.current(session) {
+			err = nil
+		} else {
+			w.stats.writeError(err)
+			w.hooks.disconnect(err)
+			if err = w.connect(); err != nil {
+				w.stats.writeError(err)
+			} else {
+				w.stats.reconnects.Add(1)
+			}
+		}
+		w.mu.Unlock()
+		if err != nil {
+			w.relp.remove(f)
+			return err
+		}
+	}
+}
+
+// startSigning signs the messages written with the signer, sending the
+// certificate blocks right away.
+func (w *writer) startSigning(s *signer) error {
+	w.mu.Lock()
+	w.signer = s
+	err := w.sendCertificateLocked()
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	s.start(w)
+
+	return nil
+}
+
+// sendSignature sends the signature block of the messages written since the
+// last one, if any.
+func (w *writer) sendSignature() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return net.ErrClosed
+	}
+
+	return w.sendSignatureLocked()
+}
+
+// sendSignatureLocked is sendSignature for callers holding the lock. The
+// hashes are lost if sending fails, which the verifier reports as a missing
+// block.
+func (w *writer) sendSignatureLocked() error {
+	b, err := w.signer.signatureBlock()
+	if err != nil || b == nil {
+		return err
+	}
+
+	return w.sendOrSpool(b)
+}
+
+// sendCertificate sends the certificate blocks.
+func (w *writer) sendCertificate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return net.ErrClosed
+	}
+
+	return w.sendCertificateLocked()
+}
+
+// sendCertificateLocked is sendCertificate for callers holding the lock.
+func (w *writer) sendCertificateLocked() error {
+	blocks, err := w.signer.certificateBlocks()
+	if err != nil {
+		return err
+	}
+	for _, b := range blocks {
+		if err := w.sendOrSpool(b); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// written counts the message written.
+func (w *writer) written(b []byte) {
+	w.stats.written.Add(1)
+	w.stats.bytes.Add(uint64(len(b)))
+}
+
+// writeConn writes b to the current connection. The caller must hold the lock.
+func (w *writer) writeConn(b []byte) error {
+	if w.writeTimeout > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.writeTimeout))
+	}
+
+	if w.gelf {
+		if w.stream {
+			bufs := net.Buffers{b, []byte{0}}
+			_, err := bufs.WriteTo(w.conn)
+			return err
+		}
+		return writeGELFChunks(w.conn, b, w.chunkSize, w.compression)
+	}
+
+	if w.octetCounting {
+		var count [20]byte
+		bufs := net.Buffers{append(strconv.AppendInt(count[:0], int64(len(b)), 10), ' '), b}
+		_, err := bufs.WriteTo(w.conn)
+		return err
+	}
+
+	_, err := w.conn.Write(b)
+
+	return err
+}
+
+// close sends the last signature block, if signing, and closes the connection
+// to the syslog server, once it acknowledged the messages over RELP. Writing to
+// a closed writer returns [net.ErrClosed].
+func (w *writer) close() error {
+	w.mu.Lock()
+	s, sp := w.signer, w.spool
+	w.mu.Unlock()
+	// The goroutines of the signer and the spool take the lock.
+	if s != nil {
+		s.close()
+	}
+	if sp != nil {
+		sp.stopReplay()
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var errs []error
+	if s != nil {
+		errs = append(errs, w.sendSignatureLocked())
+	}
+	if sp != nil {
+		// The messages that can't be replayed are left for the next handler.
+		w.replayLocked()
+		errs = append(errs, sp.close())
+	}
+	if w.relp != nil {
+		// The messages that weren't acknowledged before the connection was
+		// lost are resent once more.
+		if err := w.relp.unsent(); err != nil {
+			if w.conn != nil {
+				w.stats.writeError(err)
+				w.hooks.disconnect(err)
+			}
+			if err := w.connect(); err != nil {
+				w.stats.writeError(err)
+			} else {
+				w.stats.reconnects.Add(1)
+			}
+		}
+		// The RELP session closes the connection once the server acknowledged
+		// the messages.
+		errs = append(errs, w.relp.close(w.conn))
+	} else if w.conn != nil {
+		errs = append(errs, w.conn.Close())
+	}
+	if w.conn == nil {
+		return errors.Join(errs...)
+	}
+	w.conn = nil
+	w.hooks.disconnect(nil)
+
+	return errors.Join(errs...)
+}
@@ -1,6 +1,8 @@
 package slogsyslog
 
 import (
+	"fmt"
+	"log/slog"
 	"strconv"
 	"strings"
 )
@@ -106,10 +108,604 @@ func (f Facility) String() string {
 	}
 }
 
+// facilityNames maps the lower case names of facilities, including aliases,
+// to facilities.
+var facilityNames = map[string]Facility{
+	"kern":     Kern,
+	"user":     User,
+	"mail":     Mail,
+	"daemon":   Daemon,
+	"auth":     Auth,
+	"security": Auth,
+	"syslog":   Syslog,
+	"lpr":      LPR,
+	"news":     News,
+	"uucp":     UUCP,
+	"cron":     Cron,
+	"authpriv": AuthPriv,
+	"ftp":      FTP,
+	"local0":   Local0,
+	"local1":   Local1,
+	"local2":   Local2,
+	"local3":   Local3,
+	"local4":   Local4,
+	"local5":   Local5,
+	"local6":   Local6,
+	"local7":   Local7,
+}
+
+// ParseFacility parses a case-insensitive facility name, such as local0, or a
+// facility code from 0 to 23, such as 16 for Local0.
+func ParseFacility(s string) (Facility, error) {
+	if f, ok := facilityNames[strings.ToLower(s)]; ok {
+		return f, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 23 && Facility(n<<3).valid() {
+		return Facility(n << 3), nil
+	}
+
+	return 0, fmt.Errorf("slogsyslog: unknown facility %q", s)
+}
+
+// valid reports whether the facility is one of the named facilities.
+func (f Facility) valid() bool {
+	return f >= Kern && f <= Local7 && f&7 == 0 && (f <= FTP || f >= Local0)
+}
+
+// MarshalText returns the facility's lower case name.
+func (f Facility) MarshalText() ([]byte, error) {
+	if !f.valid() {
+		return nil, fmt.Errorf("slogsyslog: invalid facility %s", f)
+	}
+
+	return []byte(strings.ToLower(f.String())), nil
+}
+
+// UnmarshalText parses the facility as [ParseFacility] does.
+func (f *Facility) UnmarshalText(text []byte) error {
+	facility, err := ParseFacility(string(text))
+	if err != nil {
+		return err
+	}
+	*f = facility
+
+	return nil
+}
+
+// Severity is the log severity.
+type Severity int
+
+// Log severities.
+const (
+	Emerg Severity = iota
+
+	Alert
+
+	Crit
+
+	Err
+
+	Warning
+
+	Notice
+
+	Info
+
+	Debug
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Emerg:
+		return "Emerg"
+	case Alert:
+		return "Alert"
+	case Crit:
+		return "Crit"
+	case Err:
+		return "Err"
+	case Warning:
+		return "Warning"
+	case Notice:
+		return "Notice"
+	case Info:
+		return "Info"
+	case Debug:
+		return "Debug"
+	default:
+		return "Severity(" + strconv.FormatInt(int64(s), 10) + ")"
+	}
+}
+
+// severityNames maps the lower case names of severities, including aliases,
+// to severities.
+var severityNames = map[string]Severity{
+	"emerg":   Emerg,
+	"panic":   Emerg,
+	"alert":   Alert,
+	"crit":    Crit,
+	"err":     Err,
+	"error":   Err,
+	"warning": Warning,
+	"warn":    Warning,
+	"notice":  Notice,
+	"info":    Info,
+	"debug":   Debug,
+}
+
+// ParseSeverity parses a case-insensitive severity name, such as warning, or a
+// severity code from 0 to 7.
+func ParseSeverity(s string) (Severity, error) {
+	if sev, ok := severityNames[strings.ToLower(s)]; ok {
+		return sev, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= int(Emerg) && n <= int(Debug) {
+		return Severity(n), nil
+	}
+
+	return 0, fmt.Errorf("slogsyslog: unknown severity %q", s)
+}
+
+// MarshalText returns the severity's lower case name.
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < Emerg || s > Debug {
+		return nil, fmt.Errorf("slogsyslog: invalid severity %s", s)
+	}
+
+	return []byte(strings.ToLower(s.String())), nil
+}
+
+// UnmarshalText parses the severity as [ParseSeverity] does.
+func (s *Severity) UnmarshalText(text []byte) error {
+	sev, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sev
+
+	return nil
+}
+
+// Level returns the slog level matching the severity. It allows severities to
+// be used as a [log/slog.Leveler].
+func (s Severity) Level() slog.Level { return severityToLevel(s) }
+
+// Format is the syslog message format.
+type Format int
+
+// Syslog message formats.
+const (
+	// FormatAuto uses FormatLocal when connected to a UNIX socket and FormatGo
+	// otherwise.
+	FormatAuto Format = iota
+
+	// FormatGo is the format used by the syslog package from the standard
+	// library.
+	FormatGo
+
+	// FormatLocal is the format used by the syslog package from the standard
+	// library when connected to a UNIX socket.
+	FormatLocal
+
+	// FormatRFC3164 is the BSD syslog format.
+	FormatRFC3164
+
+	// FormatRFC5424 is the syslog protocol format. It is framed using octet
+	// counting on stream oriented networks.
+	FormatRFC5424
+
+	// FormatCEE is the BSD syslog format with the message being a JSON object
+	// prefixed by the "@cee:" cookie as expected by rsyslog's mmjsonparse.
+	FormatCEE
+
+	// FormatCEF is the BSD syslog format with the message in ArcSight's Common
+	// Event Format.
+	FormatCEF
+
+	// FormatLEEF is the BSD syslog format with the message in QRadar's Log
+	// Event Extended Format version 2.0.
+	FormatLEEF
+
+	// FormatGELF is the Graylog Extended Log Format version 1.1. It is sent in
+	// chunked datagrams on UDP and delimited by NUL bytes on TCP.
+	FormatGELF
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatAuto:
+		return "Auto"
+	case FormatGo:
+		return "Go"
+	case FormatLocal:
+		return "Local"
+	case FormatRFC3164:
+		return "RFC3164"
+	case FormatRFC5424:
+		return "RFC5424"
+	case FormatCEE:
+		return "CEE"
+	case FormatCEF:
+		return "CEF"
+	case FormatLEEF:
+		return "LEEF"
+	case FormatGELF:
+		return "GELF"
+	default:
+		return "Format(" + strconv.FormatInt(int64(f), 10) + ")"
+	}
+}
+
+// ParseFormat parses a case-insensitive format name, such as rfc5424.
+func ParseFormat(s string) (Format, error) {
+	for f := FormatAuto; f <= FormatGELF; f++ {
+		if strings.EqualFold(f.String(), s) {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("slogsyslog: unknown format %q", s)
+}
+
+// MarshalText returns the format's lower case name.
+func (f Format) MarshalText() ([]byte, error) {
+	if f < FormatAuto || f > FormatGELF {
+		return nil, fmt.Errorf("slogsyslog: invalid format %s", f)
+	}
+
+	return []byte(strings.ToLower(f.String())), nil
+}
+
+// UnmarshalText parses the format as [ParseFormat] does.
+func (f *Format) UnmarshalText(text []byte) error {
+	format, err := ParseFormat(string(text))
+	if err != nil {
+		return err
+	}
+	*f = format
+
+	return nil
+}
+
+// HostnameMode determines the host's name written in the message header.
+type HostnameMode int
+
+// Hostname modes.
+const (
+	// HostnameOS is the host's name as reported by the operating system.
+	HostnameOS HostnameMode = iota
+
+	// HostnameShort is the host's name up to the first dot.
+	HostnameShort
+
+	// HostnameFQDN is the host's fully qualified domain name as known by the
+	// local resolver.
+	HostnameFQDN
+
+	// HostnameIP is the IP address of the interface used to connect to the
+	// syslog server, which is found again whenever reconnecting. The host's
+	// name is used until connected to a server other than a UNIX socket.
+	HostnameIP
+)
+
+func (m HostnameMode) String() string {
+	switch m {
+	case HostnameOS:
+		return "OS"
+	case HostnameShort:
+		return "Short"
+	case HostnameFQDN:
+		return "FQDN"
+	case HostnameIP:
+		return "IP"
+	default:
+		return "HostnameMode(" + strconv.FormatInt(int64(m), 10) + ")"
+	}
+}
+
+// AttrPlacement is the placement of the attributes in the BSD formats.
+type AttrPlacement int
+
+// Attribute placements.
+const (
+	// AttrsBeforeMessage places the attributes between the tag and the
+	// message.
+	AttrsBeforeMessage AttrPlacement = iota
+
+	// AttrsAfterMessage places the attributes after the message.
+	AttrsAfterMessage
+
+	// AttrsOmitted leaves the attributes out.
+	AttrsOmitted
+)
+
+func (p AttrPlacement) String() string {
+	switch p {
+	case AttrsBeforeMessage:
+		return "BeforeMessage"
+	case AttrsAfterMessage:
+		return "AfterMessage"
+	case AttrsOmitted:
+		return "Omitted"
+	default:
+		return "AttrPlacement(" + strconv.FormatInt(int64(p), 10) + ")"
+	}
+}
+
+// AttrStyle is the syntax of the attributes in the BSD formats.
+type AttrStyle int
+
+// Attribute styles.
+const (
+	// AttrStyleBracketed writes the attributes as key="value" pairs enclosed
+	// in square brackets.
+	AttrStyleBracketed AttrStyle = iota
+
+	// AttrStyleLogfmt writes the attributes as key=value pairs without
+	// brackets, quoting keys and values only when needed just like
+	// [log/slog.TextHandler] does.
+	AttrStyleLogfmt
+)
+
+func (s AttrStyle) String() string {
+	switch s {
+	case AttrStyleBracketed:
+		return "Bracketed"
+	case AttrStyleLogfmt:
+		return "Logfmt"
+	default:
+		return "AttrStyle(" + strconv.FormatInt(int64(s), 10) + ")"
+	}
+}
+
+// EscapeMode determines how control characters in messages are escaped.
+type EscapeMode int
+
+// Escape modes.
+const (
+	// EscapeOctal escapes control characters just like rsyslog does, writing
+	// a new line as #012.
+	EscapeOctal EscapeMode = iota
+
+	// EscapeBackslash escapes control characters just like Go does, writing a
+	// new line as \n.
+	EscapeBackslash
+
+	// EscapeNone leaves control characters and invalid UTF-8 as they are.
+	EscapeNone
+)
+
+func (m EscapeMode) String() string {
+	switch m {
+	case EscapeOctal:
+		return "Octal"
+	case EscapeBackslash:
+		return "Backslash"
+	case EscapeNone:
+		return "None"
+	default:
+		return "EscapeMode(" + strconv.FormatInt(int64(m), 10) + ")"
+	}
+}
+
+// SDNameMode determines how attribute keys that aren't valid RFC 5424
+// SD-NAMEs are repaired.
+type SDNameMode int
+
+// SD-NAME modes.
+const (
+	// SDNameReplace replaces invalid characters by underscores.
+	SDNameReplace SDNameMode = iota
+
+	// SDNameEscape percent-encodes invalid characters.
+	SDNameEscape
+)
+
+func (m SDNameMode) String() string {
+	switch m {
+	case SDNameReplace:
+		return "Replace"
+	case SDNameEscape:
+		return "Escape"
+	default:
+		return "SDNameMode(" + strconv.FormatInt(int64(m), 10) + ")"
+	}
+}
+
+// DuplicatePolicy determines which attributes with the same key are written.
+// Keys are compared after being prefixed by their groups, and the handler's
+// attributes, the record's and the source are all taken into account.
+type DuplicatePolicy int
+
+// Duplicate key policies.
+const (
+	// DuplicatesKeep writes all the attributes.
+	DuplicatesKeep DuplicatePolicy = iota
+
+	// DuplicatesLastWins writes the value of the last attribute in place of
+	// the first one.
+	DuplicatesLastWins
+
+	// DuplicatesFirstWins writes the first attribute only.
+	DuplicatesFirstWins
+
+	// DuplicatesRename suffixes the keys of the following attributes with
+	// their number, such as user_2 for the second user attribute.
+	DuplicatesRename
+)
+
+func (p DuplicatePolicy) String() string {
+	switch p {
+	case DuplicatesKeep:
+		return "Keep"
+	case DuplicatesLastWins:
+		return "LastWins"
+	case DuplicatesFirstWins:
+		return "FirstWins"
+	case DuplicatesRename:
+		return "Rename"
+	default:
+		return "DuplicatePolicy(" + strconv.FormatInt(int64(p), 10) + ")"
+	}
+}
+
+// SampleBy determines which records share the limits of a sampling rule.
+type SampleBy int
+
+// Sampling keys.
+const (
+	// SampleByLevel limits all the records of the rule's levels together.
+	SampleByLevel SampleBy = iota
+
+	// SampleByMessage limits the records with the same message together.
+	SampleByMessage
+
+	// SampleByAttr limits the records with the same value of the rule's
+	// attribute together.
+	SampleByAttr
+)
+
+func (b SampleBy) String() string {
+	switch b {
+	case SampleByLevel:
+		return "Level"
+	case SampleByMessage:
+		return "Message"
+	case SampleByAttr:
+		return "Attr"
+	default:
+		return "SampleBy(" + strconv.FormatInt(int64(b), 10) + ")"
+	}
+}
+
+// DropReason is why a record wasn't written.
+type DropReason int
+
+// Drop reasons.
+const (
+	// DropSampled is a record dropped by sampling.
+	DropSampled DropReason = iota
+
+	// DropRepeated is a record suppressed as a repetition of the previous
+	// one.
+	DropRepeated
+
+	// DropInvalid is a record rejected in the strict mode.
+	DropInvalid
+
+	// DropWriteError is a record that failed to be written.
+	DropWriteError
+
+	// DropSpool is a message discarded from the spool because it exceeded its
+	// size or age bound or was corrupted. Such messages are counted but not
+	// passed to OnDrop, as the spool doesn't keep their records.
+	DropSpool
+
+	// dropReasons is the number of drop reasons.
+	dropReasons
+)
+
+func (r DropReason) String() string {
+	switch r {
+	case DropSampled:
+		return "sampled"
+	case DropRepeated:
+		return "repeated"
+	case DropInvalid:
+		return "invalid"
+	case DropWriteError:
+		return "write_error"
+	case DropSpool:
+		return "spool"
+	default:
+		return "DropReason(" + strconv.FormatInt(int64(r), 10) + ")"
+	}
+}
+
+// MarshalText returns the reason's name, which makes it usable as a key of
+// JSON objects.
+func (r DropReason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Compression is the compression of GELF messages sent in datagrams.
+type Compression int
+
+// GELF compressions.
+const (
+	// CompressionNone sends messages uncompressed.
+	CompressionNone Compression = iota
+
+	// CompressionGzip compresses messages with gzip.
+	CompressionGzip
+
+	// CompressionZlib compresses messages with zlib.
+	CompressionZlib
+)
+
+func (c Compression) String() string {
+	switch c {
+	case CompressionNone:
+		return "None"
+	case CompressionGzip:
+		return "Gzip"
+	case CompressionZlib:
+		return "Zlib"
+	default:
+		return "Compression(" + strconv.FormatInt(int64(c), 10) + ")"
+	}
+}
+
+// Keys for attributes added by the [Receiver] to the records it creates from
+// syslog messages.
+const (
+	// FacilityKey is the key used for the message's facility.
+	FacilityKey = "facility"
+
+	// HostnameKey is the key used for the message's hostname.
+	HostnameKey = "hostname"
+
+	// AppNameKey is the key used for the message's application name or tag.
+	AppNameKey = "app_name"
+
+	// ProcIDKey is the key used for the message's process ID.
+	ProcIDKey = "procid"
+
+	// MsgIDKey is the key used for the message's ID. The handler writes it as
+	// the message ID of the RFC 5424 format as well.
+	MsgIDKey = "msgid"
+)
+
+// EventIDKey is the key of the attribute holding the event's ID in the CEF and
+// LEEF formats. The message is used when it's missing.
+const EventIDKey = "event_id"
+
 // structuredEscape escapes all control characters in structured values.
 var structuredEscape = strings.NewReplacer(`"`, `\"`, `\`, `\\`, `]`, `\]`)
 
+// headerEscape escapes the CEF and LEEF header fields.
+var headerEscape = strings.NewReplacer(`|`, `\|`, `\`, `\\`, "\n", " ", "\r", " ")
+
+// cefEscape escapes the CEF extension values.
+var cefEscape = strings.NewReplacer(`=`, `\=`, `\`, `\\`, "\n", `\n`, "\r", `\r`)
+
+// leefEscape escapes the LEEF attribute values delimited by tabs.
+var leefEscape = strings.NewReplacer("\t", `\t`, "\n", `\n`, "\r", `\r`)
+
 const (
+	// DefaultSDID is the default ID of the structured data element holding the
+	// attributes in the RFC 5424 format. 32473 is the private enterprise number
+	// reserved for documentation, so set your own.
+	DefaultSDID = "slog@32473"
+
+	// DefaultGELFChunkSize is the default maximum size of GELF datagrams, safe
+	// for most networks.
+	DefaultGELFChunkSize = 1420
+
+	// DefaultMaxErrorSize is the default maximum size of the values an error
+	// expands to, which keeps the message within a datagram.
+	DefaultMaxErrorSize = 1024
+
 	// maxBufferSize is the maximum capacity of a byte slice we may return to
 	// the buffer pool.
 	maxBufferSize = 16 << 10
@@ -0,0 +1,109 @@
+package slogsyslog
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseFacility(t *testing.T) {
+	testCases := [...]struct {
+		in      string
+		want    Facility
+		wantErr bool
+	}{
+		{in: "local0", want: Local0},
+		{in: "LOCAL0", want: Local0},
+		{in: "authpriv", want: AuthPriv},
+		{in: "security", want: Auth},
+		{in: "0", want: Kern},
+		{in: "19", want: Local3},
+		{in: "12", wantErr: true},
+		{in: "24", wantErr: true},
+		{in: "-1", wantErr: true},
+		{in: "local8", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseFacility(tc.in)
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("ParseFacility(%q) = %v, %v; want %v, error %t", tc.in, got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	testCases := [...]struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{in: "warning", want: Warning},
+		{in: "WARN", want: Warning},
+		{in: "err", want: Err},
+		{in: "7", want: Debug},
+		{in: "8", wantErr: true},
+		{in: "loud", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.in, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseSeverity(tc.in)
+			if (err != nil) != tc.wantErr || got != tc.want {
+				t.Errorf("ParseSeverity(%q) = %v, %v; want %v, error %t", tc.in, got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, tc := range [...]struct {
+		in      string
+		want    Format
+		wantErr bool
+	}{
+		{in: "rfc5424", want: FormatRFC5424},
+		{in: "GELF", want: FormatGELF},
+		{in: "xml", wantErr: true},
+	} {
+		got, err := ParseFormat(tc.in)
+		if (err != nil) != tc.wantErr || got != tc.want {
+			t.Errorf("ParseFormat(%q) = %v, %v; want %v, error %t", tc.in, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
+
+func TestMarshalText(t *testing.T) {
+	v := struct {
+		Facility Facility
+		Severity Severity
+		Format   Format
+	}{Local3, Warning, FormatRFC5424}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Facility":"local3","Severity":"warning","Format":"rfc5424"}`; string(b) != want {
+		t.Errorf("json.Marshal(%+v) = %s; want %s", v, b, want)
+	}
+
+	got := v
+	got.Facility, got.Severity, got.Format = 0, 0, 0
+	if err := json.Unmarshal(b, &got); err != nil || got != v {
+		t.Errorf("json.Unmarshal(%s) = %+v, %v; want %+v, nil", b, got, err, v)
+	}
+
+	for _, m := range [...]interface{ MarshalText() ([]byte, error) }{Facility(12 << 3), Facility(3), Severity(8), Format(-1)} {
+		if _, err := m.MarshalText(); err == nil {
+			t.Errorf("%v.MarshalText() = nil; want error", m)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package slogsyslog
+
+import (
+	"log/slog"
+	"strconv"
+)
+
+// keyValue is an attribute flattened into its key prefixed by its groups.
+type keyValue struct {
+	key   string
+	value slog.Value
+}
+
+// dedupKeys applies the duplicate key policy to the flattened attributes. The
+// slice is modified in place.
+func dedupKeys(kvs []keyValue, policy DuplicatePolicy) []keyValue {
+	seen := make(map[string]int, len(kvs))
+	out := kvs[:0]
+	switch policy {
+	case DuplicatesLastWins:
+		for _, kv := range kvs {
+			if i, ok := seen[kv.key]; ok {
+				out[i].value = kv.value
+				continue
+			}
+			seen[kv.key] = len(out)
+			out = append(out, kv)
+		}
+	case DuplicatesFirstWins:
+		for _, kv := range kvs {
+			if _, ok := seen[kv.key]; ok {
+				continue
+			}
+			seen[kv.key] = len(out)
+			out = append(out, kv)
+		}
+	case DuplicatesRename:
+		// A key is never renamed to one of the other attributes, whether it
+		// comes before or after.
+		for _, kv := range kvs {
+			seen[kv.key] = 0
+		}
+		written := make(map[string]bool, len(kvs))
+		for _, kv := range kvs {
+			if written[kv.key] {
+				key := kv.key
+				for n := 2; ; n++ {
+					kv.key = key + "_" + strconv.Itoa(n)
+					if _, ok := seen[kv.key]; !ok {
+						break
+					}
+				}
+				seen[kv.key] = 0
+			}
+			written[kv.key] = true
+			out = append(out, kv)
+		}
+	default:
+		return kvs
+	}
+
+	return out
+}
@@ -0,0 +1,146 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDedupKeys(t *testing.T) {
+	testCases := [...]struct {
+		name   string
+		policy DuplicatePolicy
+		want   string
+	}{
+		{
+			name:   "Keep",
+			policy: DuplicatesKeep,
+			want:   "a=1 b=2 a=3 a_2=4 a=5",
+		},
+		{
+			name:   "LastWins",
+			policy: DuplicatesLastWins,
+			want:   "a=5 b=2 a_2=4",
+		},
+		{
+			name:   "FirstWins",
+			policy: DuplicatesFirstWins,
+			want:   "a=1 b=2 a_2=4",
+		},
+		{
+			name:   "Rename",
+			policy: DuplicatesRename,
+			want:   "a=1 b=2 a_3=3 a_2=4 a_4=5",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			kvs := []keyValue{
+				{"a", slog.IntValue(1)},
+				{"b", slog.IntValue(2)},
+				{"a", slog.IntValue(3)},
+				{"a_2", slog.IntValue(4)},
+				{"a", slog.IntValue(5)},
+			}
+
+			var got []string
+			for _, kv := range dedupKeys(kvs, tc.policy) {
+				got = append(got, kv.key+"="+kv.value.String())
+			}
+			if strings.Join(got, " ") != tc.want {
+				t.Errorf("dedupKeys(kvs, %v) = %q; want %q", tc.policy, strings.Join(got, " "), tc.want)
+			}
+		})
+	}
+}
+
+func TestSyslogHandler_DuplicateKeys(t *testing.T) {
+	testCases := [...]struct {
+		name   string
+		format Format
+		policy DuplicatePolicy
+		want   string
+	}{
+		{
+			name:   "GoKeep",
+			format: FormatGo,
+			want:   `[source="SOURCE" user="a" source="x" g.user="b" g.user="d"]`,
+		},
+		{
+			name:   "GoLastWins",
+			format: FormatGo,
+			policy: DuplicatesLastWins,
+			want:   `[source="x" user="a" g.user="d"]`,
+		},
+		{
+			name:   "GoFirstWins",
+			format: FormatGo,
+			policy: DuplicatesFirstWins,
+			want:   `[source="SOURCE" user="a" g.user="b"]`,
+		},
+		{
+			name:   "RFC5424Rename",
+			format: FormatRFC5424,
+			policy: DuplicatesRename,
+			want:   `[slog@32473 source="SOURCE" user="a" source_2="x" g.user="b" g.user_2="d"]`,
+		},
+		{
+			name:   "GELFLastWins",
+			format: FormatGELF,
+			policy: DuplicatesLastWins,
+			want:   `"_source":"x","_user":"a","_g.user":"d"}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newTestHandler(t, &Options{Format: tc.format, AddSource: true, DuplicateKeys: tc.policy})
+			h := s.WithAttrs([]slog.Attr{slog.String("user", "a"), slog.String("source", "x")}).
+				WithGroup("g").
+				WithAttrs([]slog.Attr{slog.String("user", "b")}).(*SyslogHandler)
+
+			var pcs [1]uintptr
+			runtime.Callers(1, pcs[:])
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", pcs[0])
+			r.AddAttrs(slog.String("user", "d"))
+
+			opts := h.formatOptions()
+			src := recordSource(r, opts)
+			want := strings.Replace(tc.want, "SOURCE", src.File+":"+strconv.Itoa(src.Line), 1)
+			if got := string(h.formatter(context.Background(), nil, r, opts)); !strings.Contains(got, want) {
+				t.Errorf("formatter(%v) = %q; want it to contain %q", r, got, want)
+			}
+		})
+	}
+}
+
+func TestSyslogHandler_DuplicateKeys_SDElement(t *testing.T) {
+	for _, policy := range [...]DuplicatePolicy{DuplicatesKeep, DuplicatesLastWins} {
+		s := newTestHandler(t, &Options{Format: FormatRFC5424, DuplicateKeys: policy})
+		h := s.WithAttrs([]slog.Attr{
+			slog.Any("origin", SDElement{ID: "origin", Params: []SDParam{{"ip", "10.0.0.1"}}}),
+			slog.String("user", "a"),
+		}).(*SyslogHandler)
+
+		r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+		r.AddAttrs(slog.Any("meta", SDElement{ID: "meta", Params: []SDParam{{"sequenceId", "1"}}}), slog.String("user", "b"))
+
+		want := `[slog@32473 user="a" user="b"][origin ip="10.0.0.1"][meta sequenceId="1"] a message`
+		if policy == DuplicatesLastWins {
+			want = `[slog@32473 user="b"][origin ip="10.0.0.1"][meta sequenceId="1"] a message`
+		}
+		if got := string(h.formatter(context.Background(), nil, r, h.formatOptions())); !strings.HasSuffix(got, want) {
+			t.Errorf("%s: formatter(%v) = %q; want suffix %q", policy, r, got, want)
+		}
+	}
+}
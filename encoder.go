@@ -0,0 +1,156 @@
+package slogsyslog
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ValueEncoder turns an attribute holding a [log/slog.KindAny] value into the
+// attributes written instead of it. Returned attributes may repeat the key or
+// be groups, whose attributes are written with dotted keys. Returning nil falls
+// back to the default encoding, which uses the value's MarshalText method, the
+// bytes of a byte slice or the value formatted by fmt.
+type ValueEncoder func(a slog.Attr) []slog.Attr
+
+// maxExpandDepth limits how deep [ExpandValue] follows nested values, so that
+// cyclic data structures don't expand forever.
+const maxExpandDepth = 8
+
+// JSONValue is a [ValueEncoder] writing values as JSON.
+func JSONValue(a slog.Attr) []slog.Attr {
+	if defaultEncoded(a.Value.Any()) {
+		return nil
+	}
+
+	data, err := json.Marshal(a.Value.Any())
+	if err != nil {
+		return nil
+	}
+
+	return []slog.Attr{slog.String(a.Key, string(data))}
+}
+
+// ExpandValue is a [ValueEncoder] expanding maps, structs, slices and arrays
+// into attributes with dotted keys, such as user.name for the name field of a
+// user struct or tags.0 for the first tag. Struct fields are named by their
+// JSON tag if any and unexported fields are skipped.
+func ExpandValue(a slog.Attr) []slog.Attr {
+	v := reflect.ValueOf(a.Value.Any())
+	if !expandable(v) {
+		return nil
+	}
+
+	// The attributes are returned with dotted keys rather than as groups,
+	// which keeps the order of the keys whatever the format.
+	attrs := appendExpanded(nil, a.Key, v, 0)
+	if attrs == nil {
+		// Empty values are dropped like empty groups.
+		return []slog.Attr{}
+	}
+
+	return attrs
+}
+
+// RepeatValue is a [ValueEncoder] writing each element of slices and arrays as
+// an attribute of its own with the same key, which is how RFC 5424 allows a
+// parameter to have multiple values.
+func RepeatValue(a slog.Attr) []slog.Attr {
+	v := reflect.ValueOf(a.Value.Any())
+	if defaultEncoded(a.Value.Any()) || v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil
+	}
+
+	attrs := make([]slog.Attr, v.Len())
+	for i := range attrs {
+		attrs[i] = slog.Any(a.Key, v.Index(i).Interface())
+	}
+
+	return attrs
+}
+
+// defaultEncoded reports whether the value has an encoding of its own which
+// the built-in encoders keep.
+func defaultEncoded(val any) bool {
+	switch val.(type) {
+	case nil, *slog.Source, encoding.TextMarshaler:
+		return true
+	}
+
+	_, ok := byteSlice(val)
+	return ok
+}
+
+// expandable reports whether the value is expanded by [ExpandValue].
+func expandable(v reflect.Value) bool {
+	if !v.IsValid() || defaultEncoded(v.Interface()) {
+		return false
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Struct, reflect.Slice, reflect.Array:
+		return true
+	default:
+		return false
+	}
+}
+
+// appendExpanded adds the attributes the value expands to with their keys
+// prefixed by the given one.
+func appendExpanded(attrs []slog.Attr, key string, v reflect.Value, depth int) []slog.Attr {
+	if depth >= maxExpandDepth || !expandable(v) {
+		if !v.IsValid() {
+			return append(attrs, slog.Any(key, nil))
+		}
+		return append(attrs, slog.Any(key, v.Interface()))
+	}
+
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			attrs = appendExpanded(attrs, key+"."+fmt.Sprint(k.Interface()), v.MapIndex(k), depth+1)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+
+			name := f.Name
+			if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag == "-" {
+				continue
+			} else if tag != "" {
+				name = tag
+			}
+			attrs = appendExpanded(attrs, key+"."+name, v.Field(i), depth+1)
+		}
+	default:
+		for i := 0; i < v.Len(); i++ {
+			attrs = appendExpanded(attrs, key+"."+strconv.Itoa(i), v.Index(i), depth+1)
+		}
+	}
+
+	return attrs
+}
@@ -0,0 +1,139 @@
+package slogsyslog
+
+import (
+	"log/slog"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+type testUser struct {
+	Name    string `json:"name"`
+	Age     int
+	Secret  string `json:"-"`
+	private string
+}
+
+type testNode struct {
+	Next *testNode
+}
+
+func TestValueEncoder(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		enc  ValueEncoder
+		attr slog.Attr
+		want string
+	}{
+		{
+			name: "Default",
+			attr: slog.Any("tags", []string{"a", "b"}),
+			want: `[tags="[a b\]"] a message` + "\n",
+		},
+		{
+			name: "JSON",
+			enc:  JSONValue,
+			attr: slog.Any("user", testUser{Name: "bob", Age: 42, Secret: "x", private: "y"}),
+			want: `[user="{\"name\":\"bob\",\"Age\":42}"] a message` + "\n",
+		},
+		{
+			name: "JSONTextMarshaler",
+			enc:  JSONValue,
+			attr: slog.Any("addr", netip.MustParseAddr("::1")),
+			want: `[addr="::1"] a message` + "\n",
+		},
+		{
+			name: "JSONBytes",
+			enc:  JSONValue,
+			attr: slog.Any("data", []byte("abc")),
+			want: `[data="abc"] a message` + "\n",
+		},
+		{
+			name: "ExpandStruct",
+			enc:  ExpandValue,
+			attr: slog.Any("user", &testUser{Name: "bob", Age: 42}),
+			want: `[user.name="bob" user.Age="42"] a message` + "\n",
+		},
+		{
+			name: "ExpandMap",
+			enc:  ExpandValue,
+			attr: slog.Any("m", map[string]any{"b": []int{1, 2}, "a": map[int]string{1: "x"}}),
+			want: `[m.a.1="x" m.b.0="1" m.b.1="2"] a message` + "\n",
+		},
+		{
+			name: "ExpandScalar",
+			enc:  ExpandValue,
+			attr: slog.Any("n", struct{}{}),
+			want: "a message\n",
+		},
+		{
+			name: "Repeat",
+			enc:  RepeatValue,
+			attr: slog.Any("tags", []string{"a", "b"}),
+			want: `[tags="a" tags="b"] a message` + "\n",
+		},
+		{
+			name: "RepeatNotSlice",
+			enc:  RepeatValue,
+			attr: slog.Any("m", map[string]int{"a": 1}),
+			want: `[m="map[a:1\]"] a message` + "\n",
+		},
+		{
+			name: "Custom",
+			enc: func(a slog.Attr) []slog.Attr {
+				// A nested value is not encoded again.
+				return []slog.Attr{slog.String(a.Key+"_type", "slice"), slog.Any(a.Key, a.Value.Any())}
+			},
+			attr: slog.Any("tags", []string{"a", "b"}),
+			want: `[tags_type="slice" tags="[a b\]"] a message` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attr)
+
+			opts := formatOptions{ValueEncoder: tc.enc}
+			if got := string(appendBody(nil, r, opts)); got != tc.want {
+				t.Errorf("appendBody(nil, %v, %v) = %q; want %q", r, opts, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExpandValue_Cycle(t *testing.T) {
+	n := &testNode{}
+	n.Next = n
+
+	attrs := ExpandValue(slog.Any("n", n))
+	if len(attrs) != 1 {
+		t.Fatalf("ExpandValue() = %v; want 1 attribute", attrs)
+	}
+	if want := "n" + strings.Repeat(".Next", maxExpandDepth); attrs[0].Key != want {
+		t.Errorf("ExpandValue() key = %q; want %q", attrs[0].Key, want)
+	}
+}
+
+func TestFlattenAttrs_ValueEncoder(t *testing.T) {
+	r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+	r.AddAttrs(slog.Group("g", slog.Any("user", testUser{Name: "bob", Age: 42})), slog.Any("tags", []string{"a", "b"}))
+
+	var got []string
+	flattenAttrs(r, formatOptions{ValueEncoder: ExpandValue}, false, func(key string, v slog.Value) {
+		got = append(got, key+"="+v.String())
+	})
+
+	want := []string{"g.user.name=bob", "g.user.Age=42", "tags.0=a", "tags.1=b"}
+	if len(got) != len(want) {
+		t.Fatalf("flattenAttrs() = %q; want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("flattenAttrs() = %q; want %q", got, want)
+		}
+	}
+}
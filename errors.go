@@ -0,0 +1,146 @@
+package slogsyslog
+
+import (
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrorChain is the maximum number of wrapped errors that are expanded,
+// which also stops chains wrapping themselves.
+const maxErrorChain = 32
+
+// errorEncoder returns a [ValueEncoder] expanding errors and passing any other
+// value to the next encoder.
+func errorEncoder(stack bool, size int, next ValueEncoder) ValueEncoder {
+	return func(a slog.Attr) []slog.Attr {
+		if err, ok := a.Value.Any().(error); ok && err != nil {
+			return expandError(a.Key, err, stack, size)
+		}
+		if next == nil {
+			return nil
+		}
+		return next(a)
+	}
+}
+
+// errorAttrs collects the attributes of an expanded error within the maximum
+// size of their values.
+type errorAttrs struct {
+	key       string
+	left      int
+	truncated bool
+	attrs     []slog.Attr
+}
+
+// add adds the attribute truncated to the remaining size.
+func (e *errorAttrs) add(key, value string) {
+	if e.left <= 0 {
+		e.truncated = true
+		return
+	}
+
+	if len(value) > e.left {
+		n := e.left
+		for n > 0 && !utf8.RuneStart(value[n]) {
+			n--
+		}
+		value = value[:n]
+		e.truncated = true
+	}
+	e.left -= len(value)
+	if e.truncated {
+		// A truncated value is the last one, rather than having the next
+		// ones cut arbitrarily short.
+		e.left = 0
+	}
+	e.attrs = append(e.attrs, slog.String(e.key+"."+key, value))
+}
+
+// expandError returns the attributes the error expands to.
+func expandError(key string, err error, stack bool, size int) []slog.Attr {
+	e := errorAttrs{key: key, left: size}
+	e.add("msg", err.Error())
+	e.add("type", fmt.Sprintf("%T", err))
+
+	chain := unwrapChain(err)
+	for i, c := range chain {
+		prefix := "chain." + strconv.Itoa(i) + "."
+		e.add(prefix+"msg", c.Error())
+		e.add(prefix+"type", fmt.Sprintf("%T", c))
+	}
+
+	if stack {
+		if s := stackTrace(err, chain); s != "" {
+			e.add("stack", s)
+		}
+	}
+
+	if e.truncated {
+		e.attrs = append(e.attrs, slog.Bool(key+".truncated", true))
+	}
+
+	return e.attrs
+}
+
+// unwrapChain returns the errors wrapped by the error in depth-first order,
+// following both single errors and the multiple ones of [errors.Join].
+func unwrapChain(err error) []error {
+	var (
+		chain []error
+		walk  func(err error)
+	)
+	walk = func(err error) {
+		var errs []error
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			errs = []error{u.Unwrap()}
+		case interface{ Unwrap() []error }:
+			errs = u.Unwrap()
+		}
+
+		for _, e := range errs {
+			if e == nil {
+				continue
+			}
+			if len(chain) == maxErrorChain {
+				return
+			}
+			chain = append(chain, e)
+			walk(e)
+		}
+	}
+	walk(err)
+
+	return chain
+}
+
+// stackTrace returns the stack trace of the innermost error of the chain with
+// a StackTrace method, such as the errors of github.com/pkg/errors, or else the
+// error formatted with %+v if that adds anything to its message.
+func stackTrace(err error, chain []error) string {
+	for i := len(chain) - 1; i >= -1; i-- {
+		e := err
+		if i >= 0 {
+			e = chain[i]
+		}
+
+		// The method returns a type of its own, so it is looked up by name.
+		m := reflect.ValueOf(e).MethodByName("StackTrace")
+		if !m.IsValid() || m.Type().NumIn() != 0 || m.Type().NumOut() != 1 {
+			continue
+		}
+		return strings.TrimLeft(fmt.Sprintf("%+v", m.Call(nil)[0].Interface()), "\n")
+	}
+
+	if _, ok := err.(fmt.Formatter); ok {
+		if s := fmt.Sprintf("%+v", err); s != err.Error() {
+			return s
+		}
+	}
+
+	return ""
+}
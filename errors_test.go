@@ -0,0 +1,146 @@
+package slogsyslog
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// stackError mimics the errors of github.com/pkg/errors carrying a stack trace.
+type stackError struct {
+	msg   string
+	stack []string
+}
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() []string { return e.stack }
+
+// verboseError adds details when formatted with %+v.
+type verboseError struct{}
+
+func (verboseError) Error() string { return "verbose" }
+
+func (e verboseError) Format(s fmt.State, verb rune) {
+	if s.Flag('+') {
+		fmt.Fprint(s, "verbose\nat main.go:1")
+		return
+	}
+	fmt.Fprint(s, e.Error())
+}
+
+func TestExpandError(t *testing.T) {
+	base := &stackError{msg: "base", stack: []string{"main.go:1", "main.go:2"}}
+	testCases := [...]struct {
+		name  string
+		err   error
+		stack bool
+		size  int
+		want  []string
+	}{
+		{
+			name: "Simple",
+			err:  errors.New("failed"),
+			size: DefaultMaxErrorSize,
+			want: []string{"e.msg=failed", "e.type=*errors.errorString"},
+		},
+		{
+			name: "Chain",
+			err:  fmt.Errorf("wrapped: %w", base),
+			size: DefaultMaxErrorSize,
+			want: []string{
+				"e.msg=wrapped: base", "e.type=*fmt.wrapError",
+				"e.chain.0.msg=base", "e.chain.0.type=*slogsyslog.stackError",
+			},
+		},
+		{
+			name: "Join",
+			err:  errors.Join(errors.New("a"), fmt.Errorf("b: %w", base)),
+			size: DefaultMaxErrorSize,
+			want: []string{
+				"e.msg=a\nb: base", "e.type=*errors.joinError",
+				"e.chain.0.msg=a", "e.chain.0.type=*errors.errorString",
+				"e.chain.1.msg=b: base", "e.chain.1.type=*fmt.wrapError",
+				"e.chain.2.msg=base", "e.chain.2.type=*slogsyslog.stackError",
+			},
+		},
+		{
+			name:  "StackTrace",
+			err:   fmt.Errorf("wrapped: %w", base),
+			stack: true,
+			size:  DefaultMaxErrorSize,
+			want: []string{
+				"e.msg=wrapped: base", "e.type=*fmt.wrapError",
+				"e.chain.0.msg=base", "e.chain.0.type=*slogsyslog.stackError",
+				"e.stack=[main.go:1 main.go:2]",
+			},
+		},
+		{
+			name:  "Formatter",
+			err:   verboseError{},
+			stack: true,
+			size:  DefaultMaxErrorSize,
+			want:  []string{"e.msg=verbose", "e.type=slogsyslog.verboseError", "e.stack=verbose\nat main.go:1"},
+		},
+		{
+			name:  "NoStack",
+			err:   errors.New("failed"),
+			stack: true,
+			size:  DefaultMaxErrorSize,
+			want:  []string{"e.msg=failed", "e.type=*errors.errorString"},
+		},
+		{
+			name: "Truncated",
+			err:  errors.New("a very long message"),
+			size: 10,
+			want: []string{"e.msg=a very lon", "e.truncated=true"},
+		},
+		{
+			name: "TruncatedUTF8",
+			err:  errors.New("éé"),
+			size: 3,
+			want: []string{"e.msg=é", "e.truncated=true"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, a := range expandError("e", tc.err, tc.stack, tc.size) {
+				got = append(got, a.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tc.want, ",") {
+				t.Errorf("expandError(%q, %v, %t, %d) = %q; want %q", "e", tc.err, tc.stack, tc.size, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUnwrapChain_Limit(t *testing.T) {
+	err := errors.New("base")
+	for i := 0; i < 2*maxErrorChain; i++ {
+		err = fmt.Errorf("wrap %d: %w", i, err)
+	}
+
+	if chain := unwrapChain(err); len(chain) != maxErrorChain {
+		t.Errorf("len(unwrapChain(err)) = %d; want %d", len(chain), maxErrorChain)
+	}
+}
+
+func TestErrorEncoder(t *testing.T) {
+	enc := errorEncoder(false, DefaultMaxErrorSize, RepeatValue)
+
+	r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+	r.AddAttrs(slog.Any("err", errors.New("failed")), slog.Any("tags", []string{"a", "b"}), slog.Any("nil", error(nil)))
+
+	opts := formatOptions{ValueEncoder: enc, AttrStyle: AttrStyleLogfmt, AttrPlacement: AttrsAfterMessage}
+	want := `a message err.msg=failed err.type=*errors.errorString tags=a tags=b nil=<nil>` + "\n"
+	if got := string(appendBody(nil, r, opts)); got != want {
+		t.Errorf("appendBody(nil, %v, %v) = %q; want %q", r, opts, got, want)
+	}
+}
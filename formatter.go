@@ -6,6 +6,7 @@ import (
 	"log/slog"
 	"os"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -27,132 +28,621 @@ type formatOptions struct {
 	// Tag with which we are logging.
 	Tag string
 
+	// ProcID is the process ID written in the header. The ID of the current
+	// process is used when empty.
+	ProcID string
+
+	// MsgID returns the RFC 5424 message ID of records without the [MsgIDKey]
+	// attribute.
+	MsgID func(slog.Record) string
+
+	// Severity returns the severity of the records. The level is used when
+	// nil.
+	Severity func(slog.Record) Severity
+
+	// SDID is the ID of the structured data element holding the attributes in
+	// the RFC 5424 format.
+	SDID string
+
 	// Prefix value keys with group(s).
 	Prefix []byte
 
 	// preformat is a pre-generated value of attributes.
 	Preformat []byte
+
+	// Groups are the handler's groups and attributes in the order they were
+	// added.
+	Groups []groupOrAttrs
+
+	// Vendor, Product and Version of the device in the CEF and LEEF headers.
+	Vendor, Product, Version string
+
+	// ExtKeys maps attribute keys to the CEF and LEEF extension keys.
+	ExtKeys map[string]string
+
+	// AttrPlacement is the placement of the attributes in the BSD formats.
+	AttrPlacement AttrPlacement
+
+	// AttrStyle is the syntax of the attributes in the BSD formats.
+	AttrStyle AttrStyle
+
+	// AttrSeparator separates the attributes in the BSD formats.
+	AttrSeparator string
+
+	// TimePrecision is the precision of the header's timestamp.
+	TimePrecision time.Duration
+
+	// TimeLocation is the location of the timestamps.
+	TimeLocation *time.Location
+
+	// TimestampYear indicates whether the short BSD timestamp includes the
+	// year.
+	TimestampYear bool
+
+	// AttrTimeLayout is the layout of time attributes.
+	AttrTimeLayout string
+
+	// Escape determines how control characters are escaped.
+	Escape EscapeMode
+
+	// BOM indicates whether the RFC 5424 message starts with the UTF-8 byte
+	// order mark.
+	BOM bool
+
+	// SDNames determines how invalid characters in attribute keys are
+	// repaired.
+	SDNames SDNameMode
+
+	// DuplicateKeys determines which attributes with the same key are
+	// written.
+	DuplicateKeys DuplicatePolicy
+
+	// ValueEncoder encodes attribute values of kind Any if set.
+	ValueEncoder ValueEncoder
 }
 
 // messageFormatter outputs a log message based on the input options.
 type messageFormatter func(ctx context.Context, buf []byte, r slog.Record, opts formatOptions) []byte
 
+const (
+	// rfc3339Date is the layout of the date and time of RFC 3339 timestamps
+	// without the fraction of a second and the zone.
+	rfc3339Date = "2006-01-02T15:04:05"
+
+	// stampYear is the layout of the short BSD timestamp including the year.
+	stampYear = "Jan _2 2006 15:04:05"
+)
+
 // goFormat outputs a message in a format as used by the syslog package from the
 // standard library.
 func goFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
-	buf = append(buf, '<')
-	buf = strconv.AppendInt(buf, int64(opts.Facility)|levelToPriority(r.Level), 10)
-	buf = append(buf, '>')
-
-	var timestamp time.Time
-	if !r.Time.IsZero() {
-		timestamp = r.Time
-	} else {
-		timestamp = time.Now()
-	}
-
-	buf = append(buf, timestamp.Format(time.RFC3339)...)
+	buf = appendPriority(buf, r, opts)
+	buf = headerTime(r, opts).AppendFormat(buf, rfc3339Date+fractionLayout(opts.TimePrecision, time.Second)+"Z07:00")
 	buf = append(buf, ' ')
 	buf = append(buf, opts.Hostname...)
 	buf = append(buf, ' ')
-	buf = append(buf, opts.Tag...)
-	buf = append(buf, '[')
-	buf = strconv.AppendInt(buf, int64(os.Getpid()), 10)
-	buf = append(buf, ']', ':', ' ')
+	buf = appendTag(buf, opts)
 
-	var source *slog.Source
-	if opts.AddSource && r.PC != 0 {
-		fs := runtime.CallersFrames([]uintptr{r.PC})
-		f, _ := fs.Next()
+	return appendBody(buf, r, opts)
+}
 
-		source = &slog.Source{
-			Function: f.Function,
-			File:     f.File,
-			Line:     f.Line,
-		}
-	}
+// localFormat outputs a message formatted for a syslog server listening on the
+// localhost.
+func localFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = appendPriority(buf, r, opts)
+	buf = headerTime(r, opts).AppendFormat(buf, stampLayout(opts))
+	buf = append(buf, ' ')
+	buf = appendTag(buf, opts)
+
+	return appendBody(buf, r, opts)
+}
+
+// rfc3164Format outputs a message in the BSD syslog format as described in RFC
+// 3164.
+func rfc3164Format(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = appendRFC3164Header(buf, r, opts)
+
+	return appendBody(buf, r, opts)
+}
+
+// rfc5424Format outputs a message in the syslog protocol format as described in
+// RFC 5424. The attributes are written as parameters of a single structured
+// data element, except for the handler's and the record's attributes holding
+// an [SDElement] which are written as elements of their own.
+func rfc5424Format(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = appendPriority(buf, r, opts)
+	buf = append(buf, '1', ' ')
+	buf = headerTime(r, opts).AppendFormat(buf, rfc3339Date+fractionLayout(opts.TimePrecision, time.Microsecond)+"Z07:00")
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, opts.Hostname, 255)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, opts.Tag, 48)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, procID(opts), 128)
+	buf = append(buf, ' ')
+	buf = appendHeaderField(buf, recordMsgID(r, opts), 32)
+	buf = append(buf, ' ')
 
+	n := len(buf)
+	var elems []SDElement
+	opts.Groups, elems = splitSDElements(opts.Groups)
+
+	source := recordSource(r, opts)
 	if source != nil || r.NumAttrs() > 0 || len(opts.Preformat) > 0 {
 		buf = append(buf, '[')
-		if source != nil {
-			buf = appendAttr(buf, nil, slog.Any(slog.SourceKey, source))
-			buf = append(buf, ' ')
+		buf = append(buf, opts.SDID...)
+		buf = append(buf, ' ')
+		params := len(buf)
+
+		if opts.DuplicateKeys != DuplicatesKeep {
+			// The pre-formatted attributes can't be told apart, so the
+			// handler's attributes are formatted along with the record's,
+			// which are copied without the message ID and elements.
+			rest := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+			r.Attrs(func(a slog.Attr) bool {
+				if e, ok := a.Value.Any().(SDElement); ok {
+					elems = append(elems, e)
+				} else if a.Key != MsgIDKey {
+					rest.AddAttrs(a)
+				}
+				return true
+			})
+
+			flattenAttrs(rest, opts, true, func(key string, v slog.Value) {
+				buf = appendStyledValue(buf, key, v, opts)
+			})
+		} else {
+			if source != nil {
+				buf = appendAttr(buf, nil, slog.Any(slog.SourceKey, source))
+				buf = append(buf, ' ')
+			}
+			buf = append(buf, opts.Preformat...)
+
+			r.Attrs(func(a slog.Attr) bool {
+				if a.Key == MsgIDKey {
+					return true
+				}
+				if e, ok := a.Value.Any().(SDElement); ok {
+					elems = append(elems, e)
+					return true
+				}
+
+				buf = appendStyledAttr(buf, opts.Prefix, a, opts)
+				return true
+			})
 		}
-		buf = append(buf, opts.Preformat...)
 
-		r.Attrs(func(a slog.Attr) bool {
-			buf = appendAttr(buf, opts.Prefix, a)
-			buf = append(buf, ' ')
-			return true
-		})
-		buf = bytes.TrimSuffix(buf, []byte{' '})
+		if len(buf) == params {
+			buf = buf[:n]
+		} else {
+			buf = bytes.TrimSuffix(buf, []byte{' '})
+			buf = append(buf, ']')
+		}
+	}
 
-		buf = append(buf, ']', ' ')
+	for _, e := range elems {
+		buf = appendSDElement(buf, e)
+	}
+	if len(buf) == n {
+		buf = append(buf, '-')
 	}
 
-	buf = append(buf, r.Message...)
-	if !strings.HasSuffix(r.Message, "\n") {
-		buf = append(buf, '\n')
+	if r.Message != "" {
+		buf = append(buf, ' ')
+		msg := len(buf)
+		if opts.BOM {
+			buf = append(buf, bom...)
+		}
+		buf = append(buf, r.Message...)
+		buf = escapeControl(buf, msg, opts.Escape)
 	}
 
 	return buf
 }
 
-// localFormat outputs a message formatted for a syslog server listening on the
-// localhost.
-func localFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+// splitSDElements returns the groups and attributes of the handler without
+// the attributes holding an [SDElement], which are returned separately.
+func splitSDElements(goas []groupOrAttrs) ([]groupOrAttrs, []SDElement) {
+	if !slices.ContainsFunc(goas, func(goa groupOrAttrs) bool {
+		return slices.ContainsFunc(goa.attrs, isSDElement)
+	}) {
+		return goas, nil
+	}
+
+	// The groups and attributes are shared with the derived handlers, so
+	// they are copied.
+	var elems []SDElement
+	rest := make([]groupOrAttrs, 0, len(goas))
+	for _, goa := range goas {
+		var attrs []slog.Attr
+		for _, a := range goa.attrs {
+			if e, ok := a.Value.Any().(SDElement); ok {
+				elems = append(elems, e)
+			} else {
+				attrs = append(attrs, a)
+			}
+		}
+		rest = append(rest, groupOrAttrs{group: goa.group, attrs: attrs})
+	}
+
+	return rest, elems
+}
+
+// isSDElement reports whether the attribute holds an [SDElement].
+func isSDElement(a slog.Attr) bool {
+	_, ok := a.Value.Any().(SDElement)
+	return ok
+}
+
+// appendPriority adds the message's priority.
+func appendPriority(buf []byte, r slog.Record, opts formatOptions) []byte {
 	buf = append(buf, '<')
-	buf = strconv.AppendInt(buf, int64(opts.Facility)|levelToPriority(r.Level), 10)
+	buf = strconv.AppendInt(buf, int64(opts.Facility)|recordSeverity(r, opts), 10)
 	buf = append(buf, '>')
 
-	var timestamp time.Time
-	if !r.Time.IsZero() {
-		timestamp = r.Time
-	} else {
-		timestamp = time.Now()
-	}
+	return buf
+}
 
-	buf = append(buf, timestamp.Format(time.Stamp)...)
+// appendRFC3164Header adds the priority, timestamp, optional hostname and tag
+// of the BSD syslog format.
+func appendRFC3164Header(buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = appendPriority(buf, r, opts)
+	buf = headerTime(r, opts).AppendFormat(buf, stampLayout(opts))
 	buf = append(buf, ' ')
+	if opts.Hostname != "" {
+		buf = append(buf, opts.Hostname...)
+		buf = append(buf, ' ')
+	}
+
+	return appendTag(buf, opts)
+}
+
+// appendTag adds the BSD formats' tag along with the process ID.
+func appendTag(buf []byte, opts formatOptions) []byte {
 	buf = append(buf, opts.Tag...)
 	buf = append(buf, '[')
-	buf = strconv.AppendInt(buf, int64(os.Getpid()), 10)
+	buf = append(buf, procID(opts)...)
 	buf = append(buf, ']', ':', ' ')
 
-	var source *slog.Source
-	if opts.AddSource && r.PC != 0 {
-		fs := runtime.CallersFrames([]uintptr{r.PC})
-		f, _ := fs.Next()
+	return buf
+}
+
+// appendBody adds the attributes and the message of the BSD formats with the
+// attributes placed as requested. The body is terminated by a new line.
+func appendBody(buf []byte, r slog.Record, opts formatOptions) []byte {
+	start := len(buf)
+
+	switch opts.AttrPlacement {
+	case AttrsAfterMessage:
+		msg := strings.TrimSuffix(r.Message, "\n")
+		buf = append(buf, msg...)
+
+		n := len(buf)
+		if msg != "" {
+			buf = append(buf, ' ')
+		}
+		attrs := len(buf)
+		if buf = appendAttrs(buf, r, opts); len(buf) == attrs {
+			buf = buf[:n]
+		}
+	case AttrsOmitted:
+		buf = append(buf, r.Message...)
+	default:
+		n := len(buf)
+		if buf = appendAttrs(buf, r, opts); len(buf) > n {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, r.Message...)
+	}
 
-		source = &slog.Source{
-			Function: f.Function,
-			File:     f.File,
-			Line:     f.Line,
+	// A message may already end with a new line, in which case we don't add
+	// another one unless control characters are escaped.
+	if opts.Escape == EscapeNone {
+		if len(buf) > start && buf[len(buf)-1] == '\n' {
+			return buf
 		}
+		return append(buf, '\n')
 	}
 
-	if source != nil || r.NumAttrs() > 0 || len(opts.Preformat) > 0 {
+	if len(buf) > start && buf[len(buf)-1] == '\n' {
+		buf = buf[:len(buf)-1]
+	}
+	buf = escapeControl(buf, start, opts.Escape)
+
+	return append(buf, '\n')
+}
+
+// appendAttrs adds the source and all the attributes, enclosed in square
+// brackets unless written in the logfmt style, as used by the BSD formats.
+// Nothing is added when there are no attributes.
+func appendAttrs(buf []byte, r slog.Record, opts formatOptions) []byte {
+	source := recordSource(r, opts)
+	if source == nil && r.NumAttrs() == 0 && len(opts.Preformat) == 0 {
+		return buf
+	}
+
+	n := len(buf)
+	bracketed := opts.AttrStyle == AttrStyleBracketed
+	if bracketed {
 		buf = append(buf, '[')
+	}
+	attrs := len(buf)
+
+	if opts.DuplicateKeys != DuplicatesKeep {
+		// The pre-formatted attributes can't be told apart, so the handler's
+		// attributes are formatted along with the record's.
+		flattenAttrs(r, opts, true, func(key string, v slog.Value) {
+			buf = appendStyledValue(buf, key, v, opts)
+		})
+	} else {
 		if source != nil {
-			buf = appendAttr(buf, nil, slog.Any(slog.SourceKey, source))
-			buf = append(buf, ' ')
+			buf = appendStyledAttr(buf, nil, slog.Any(slog.SourceKey, source), opts)
 		}
 		buf = append(buf, opts.Preformat...)
 
 		r.Attrs(func(a slog.Attr) bool {
-			buf = appendAttr(buf, opts.Prefix, a)
-			buf = append(buf, ' ')
+			buf = appendStyledAttr(buf, opts.Prefix, a, opts)
 			return true
 		})
-		buf = bytes.TrimSuffix(buf, []byte{' '})
+	}
+
+	if len(buf) == attrs {
+		return buf[:n]
+	}
+	buf = bytes.TrimSuffix(buf, []byte(opts.attrSeparator()))
+	if bracketed {
+		buf = append(buf, ']')
+	}
+
+	return buf
+}
+
+// appendHeaderField adds a RFC 5424 header field or a nil value if empty. The
+// field is cut to its maximum length and, since only printable US-ASCII is
+// allowed, any other character is replaced by an underscore.
+func appendHeaderField(buf []byte, s string, maxLen int) []byte {
+	if s == "" {
+		return append(buf, '-')
+	}
+
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c >= '!' && c <= '~' {
+			buf = append(buf, c)
+		} else {
+			buf = append(buf, '_')
+		}
+	}
+
+	return buf
+}
 
-		buf = append(buf, ']', ' ')
+// procID returns the process ID written in the header.
+func procID(opts formatOptions) string {
+	if opts.ProcID != "" {
+		return opts.ProcID
 	}
 
-	buf = append(buf, r.Message...)
-	if !strings.HasSuffix(r.Message, "\n") {
-		buf = append(buf, '\n')
+	return strconv.Itoa(os.Getpid())
+}
+
+// recordSeverity returns the record's syslog severity taken from the Severity
+// option or its level.
+func recordSeverity(r slog.Record, opts formatOptions) int64 {
+	if opts.Severity != nil {
+		return int64(opts.Severity(r))
 	}
 
+	return levelToPriority(r.Level)
+}
+
+// recordMsgID returns the record's message ID taken from its [MsgIDKey]
+// attribute or the MsgID option.
+func recordMsgID(r slog.Record, opts formatOptions) string {
+	var id string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == MsgIDKey {
+			id = resolveValue(a.Value).String()
+			return false
+		}
+		return true
+	})
+	if id == "" && opts.MsgID != nil {
+		id = opts.MsgID(r)
+	}
+
+	return id
+}
+
+// appendSDElement adds a RFC 5424 structured data element.
+func appendSDElement(buf []byte, e SDElement) []byte {
+	buf = append(buf, '[')
+	buf = append(buf, e.ID...)
+	for _, p := range e.Params {
+		buf = append(buf, ' ')
+		buf = append(buf, p.Name...)
+		buf = append(buf, '=', '"')
+		buf = append(buf, structuredEscape.Replace(p.Value)...)
+		buf = append(buf, '"')
+	}
+	buf = append(buf, ']')
+
 	return buf
 }
+
+// flattenAttrs calls f for the source, the handler's and the record's
+// attributes with their keys prefixed by the groups joined by dots, applying
+// the duplicate key policy. The groups of attributes nested in groups prefix
+// the keys in reverse order if reversed is set, as the BSD and RFC 5424
+// formats always did. Groups are never passed to f, while empty attributes are
+// skipped.
+func flattenAttrs(r slog.Record, opts formatOptions, reversed bool, f func(key string, v slog.Value)) {
+	if opts.DuplicateKeys == DuplicatesKeep {
+		walkAttrs(r, opts, reversed, f)
+		return
+	}
+
+	var kvs []keyValue
+	walkAttrs(r, opts, reversed, func(key string, v slog.Value) {
+		kvs = append(kvs, keyValue{key, v})
+	})
+	for _, kv := range dedupKeys(kvs, opts.DuplicateKeys) {
+		f(kv.key, kv.value)
+	}
+}
+
+// walkAttrs calls f for the source, the handler's and the record's attributes
+// as flattened by flattenAttr.
+func walkAttrs(r slog.Record, opts formatOptions, reversed bool, f func(key string, v slog.Value)) {
+	if source := recordSource(r, opts); source != nil {
+		f(slog.SourceKey, slog.AnyValue(source))
+	}
+
+	var prefix string
+	for _, goa := range opts.Groups {
+		if goa.group != "" {
+			if reversed {
+				prefix = goa.group + "." + prefix
+			} else {
+				prefix += goa.group + "."
+			}
+			continue
+		}
+
+		for _, a := range goa.attrs {
+			flattenAttr(prefix, a, opts.ValueEncoder, reversed, 0, f)
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		flattenAttr(prefix, a, opts.ValueEncoder, reversed, 0, f)
+		return true
+	})
+}
+
+// flattenAttr calls f for the attribute or the attributes of a group.
+func flattenAttr(prefix string, a slog.Attr, enc ValueEncoder, reversed bool, depth int, f func(key string, v slog.Value)) {
+	a.Value = limitDepth(resolveValue(a.Value), depth)
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindAny && enc != nil {
+		// The attributes returned by the encoder aren't encoded again, which
+		// could go on forever.
+		if attrs := encodeValue(enc, a); attrs != nil {
+			for _, ea := range attrs {
+				flattenAttr(prefix, ea, nil, reversed, depth, f)
+			}
+			return
+		}
+	}
+
+	if a.Value.Kind() != slog.KindGroup {
+		f(prefix+a.Key, a.Value)
+		return
+	}
+
+	if a.Key != "" {
+		if reversed {
+			prefix = a.Key + "." + prefix
+		} else {
+			prefix += a.Key + "."
+		}
+	}
+	for _, ga := range a.Value.Group() {
+		flattenAttr(prefix, ga, enc, reversed, depth+1, f)
+	}
+}
+
+// headerTime returns the record's time in the requested location.
+func headerTime(r slog.Record, opts formatOptions) time.Time {
+	t := recordTime(r)
+	if opts.TimeLocation != nil {
+		t = t.In(opts.TimeLocation)
+	}
+
+	return t
+}
+
+// stampLayout returns the layout of the short BSD timestamp.
+func stampLayout(opts formatOptions) string {
+	layout := time.Stamp
+	if opts.TimestampYear {
+		layout = stampYear
+	}
+
+	return layout + fractionLayout(opts.TimePrecision, time.Second)
+}
+
+// fractionLayout returns the layout of the fraction of a second for the
+// precision, being at most microseconds, or the default one when not set.
+func fractionLayout(precision, def time.Duration) string {
+	if precision <= 0 {
+		precision = def
+	}
+
+	switch {
+	case precision >= time.Second:
+		return ""
+	case precision >= time.Millisecond:
+		return ".000"
+	default:
+		return ".000000"
+	}
+}
+
+// attrTime returns the time value in the requested location, formatted by the
+// requested layout if any. Other values are returned as they are.
+func attrTime(v slog.Value, opts formatOptions) slog.Value {
+	if v.Kind() != slog.KindTime {
+		return v
+	}
+
+	t := v.Time()
+	if opts.TimeLocation != nil {
+		t = t.In(opts.TimeLocation)
+	}
+	if opts.AttrTimeLayout != "" {
+		return slog.StringValue(t.Format(opts.AttrTimeLayout))
+	}
+
+	return slog.TimeValue(t)
+}
+
+// attrSeparator returns the separator of attributes in the BSD formats.
+func (o formatOptions) attrSeparator() string {
+	if o.AttrSeparator == "" {
+		return " "
+	}
+
+	return o.AttrSeparator
+}
+
+// recordTime returns the record's time or the current time if not set.
+func recordTime(r slog.Record) time.Time {
+	if !r.Time.IsZero() {
+		return r.Time
+	}
+
+	return time.Now()
+}
+
+// recordSource returns the source code position of the record's log statement
+// if requested.
+func recordSource(r slog.Record, opts formatOptions) *slog.Source {
+	if !opts.AddSource || r.PC == 0 {
+		return nil
+	}
+
+	fs := runtime.CallersFrames([]uintptr{r.PC})
+	f, _ := fs.Next()
+
+	return &slog.Source{
+		Function: f.Function,
+		File:     f.File,
+		Line:     f.Line,
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -48,7 +49,7 @@ func TestGoFormat(t *testing.T) {
 		{
 			name: "Quoted",
 			attr: slog.String("x = y", `qu"o`),
-			want: []byte("<6>2000-01-02T03:04:05Z localhost test[" + pid + "]: [x = y=\"qu\\\"o\"] a message\n"),
+			want: []byte("<6>2000-01-02T03:04:05Z localhost test[" + pid + "]: [x___y=\"qu\\\"o\"] a message\n"),
 		},
 		{
 			name: "String",
@@ -113,7 +114,7 @@ func TestLocalFormat(t *testing.T) {
 		{
 			name: "Quoted",
 			attr: slog.String("x = y", `qu"o`),
-			want: []byte("<6>Jan  2 03:04:05 test[" + pid + "]: [x = y=\"qu\\\"o\"] a message\n"),
+			want: []byte("<6>Jan  2 03:04:05 test[" + pid + "]: [x___y=\"qu\\\"o\"] a message\n"),
 		},
 		{
 			name: "String",
@@ -161,3 +162,304 @@ func TestLocalFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestRFC3164Format(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	testCases := [...]struct {
+		name     string
+		hostname string
+		attr     slog.Attr
+		want     []byte
+	}{
+		{
+			name:     "Hostname",
+			hostname: "localhost",
+			attr:     slog.Int("a", 1),
+			want:     []byte("<6>Jan  2 03:04:05 localhost test[" + pid + "]: [a=\"1\"] a message\n"),
+		},
+		{
+			name: "NoHostname",
+			attr: slog.Int("a", 1),
+			want: []byte("<6>Jan  2 03:04:05 test[" + pid + "]: [a=\"1\"] a message\n"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := formatOptions{
+				Hostname: tc.hostname,
+				Tag:      "test",
+			}
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attr)
+
+			buf := make([]byte, 0, 1024)
+			buf = rfc3164Format(context.Background(), buf, r, opts)
+			if !bytes.Equal(buf, tc.want) {
+				t.Errorf("rfc3164Format(ctx, buf, %v, %v) = %s; want %s", r, opts, buf, tc.want)
+			}
+		})
+	}
+}
+
+func TestRFC5424Format(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	testCases := [...]struct {
+		name      string
+		preformat []byte
+		attrs     []slog.Attr
+		want      []byte
+	}{
+		{
+			name: "NoAttrs",
+			want: []byte("<14>1 2000-01-02T03:04:05.000000Z localhost test " + pid + " - - a message"),
+		},
+		{
+			name:  "Attrs",
+			attrs: []slog.Attr{slog.Int("a", 1), slog.String("b", `qu"o]`)},
+			want:  []byte("<14>1 2000-01-02T03:04:05.000000Z localhost test " + pid + " - [id@32473 a=\"1\" b=\"qu\\\"o\\]\"] a message"),
+		},
+		{
+			name:      "Preformat",
+			preformat: []byte("p=\"1\" "),
+			want:      []byte("<14>1 2000-01-02T03:04:05.000000Z localhost test " + pid + " - [id@32473 p=\"1\"] a message"),
+		},
+		{
+			name: "SDElements",
+			attrs: []slog.Attr{
+				slog.Any("zoo@123", SDElement{ID: "zoo@123", Params: []SDParam{{"tiger", "hungry"}}}),
+				slog.Any("manager@123", SDElement{ID: "manager@123"}),
+			},
+			want: []byte("<14>1 2000-01-02T03:04:05.000000Z localhost test " + pid + " - [zoo@123 tiger=\"hungry\"][manager@123] a message"),
+		},
+		{
+			name: "Mixed",
+			attrs: []slog.Attr{
+				slog.Any("zoo@123", SDElement{ID: "zoo@123", Params: []SDParam{{"tiger", "hungry"}}}),
+				slog.Int("a", 1),
+			},
+			want: []byte("<14>1 2000-01-02T03:04:05.000000Z localhost test " + pid + " - [id@32473 a=\"1\"][zoo@123 tiger=\"hungry\"] a message"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := formatOptions{
+				Hostname:  "localhost",
+				Facility:  User,
+				Tag:       "test",
+				SDID:      "id@32473",
+				Preformat: tc.preformat,
+			}
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attrs...)
+
+			buf := make([]byte, 0, 1024)
+			buf = rfc5424Format(context.Background(), buf, r, opts)
+			if !bytes.Equal(buf, tc.want) {
+				t.Errorf("rfc5424Format(ctx, buf, %v, %v) = %s; want %s", r, opts, buf, tc.want)
+			}
+		})
+	}
+}
+
+func TestAppendBody(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		opts formatOptions
+		msg  string
+		want string
+	}{
+		{
+			name: "Before",
+			msg:  "a message",
+			want: `[a="1" g.b="x y" g.c="2"] a message` + "\n",
+		},
+		{
+			name: "After",
+			opts: formatOptions{AttrPlacement: AttrsAfterMessage},
+			msg:  "a message\n",
+			want: `a message [a="1" g.b="x y" g.c="2"]` + "\n",
+		},
+		{
+			name: "AfterNoMessage",
+			opts: formatOptions{AttrPlacement: AttrsAfterMessage},
+			want: `[a="1" g.b="x y" g.c="2"]` + "\n",
+		},
+		{
+			name: "Omitted",
+			opts: formatOptions{AttrPlacement: AttrsOmitted},
+			msg:  "a message",
+			want: "a message\n",
+		},
+		{
+			name: "Logfmt",
+			opts: formatOptions{AttrPlacement: AttrsAfterMessage, AttrStyle: AttrStyleLogfmt},
+			msg:  "a message",
+			want: `a message a=1 g.b="x y" g.c=2` + "\n",
+		},
+		{
+			name: "Separator",
+			opts: formatOptions{AttrStyle: AttrStyleLogfmt, AttrSeparator: ", "},
+			msg:  "a message",
+			want: `a=1, g.b="x y", g.c=2 a message` + "\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, tc.msg, 0)
+			r.AddAttrs(slog.Int("a", 1), slog.Attr{}, slog.Group("g", slog.String("b", "x y"), slog.Int("c", 2)))
+
+			if got := string(appendBody(nil, r, tc.opts)); got != tc.want {
+				t.Errorf("appendBody(nil, %v, %v) = %q; want %q", r, tc.opts, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestAppendBody_NoAttrs(t *testing.T) {
+	for _, p := range [...]AttrPlacement{AttrsBeforeMessage, AttrsAfterMessage, AttrsOmitted} {
+		r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+		r.AddAttrs(slog.Attr{})
+
+		opts := formatOptions{AttrPlacement: p}
+		if got, want := string(appendBody(nil, r, opts)), "a message\n"; got != want {
+			t.Errorf("appendBody(nil, %v, %v) = %q; want %q", r, opts, got, want)
+		}
+	}
+}
+
+func TestFormat_Time(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+	loc := time.FixedZone("CET", 3600)
+	tm := time.Date(2000, 1, 2, 3, 4, 5, 123456789, time.UTC)
+
+	testCases := [...]struct {
+		name      string
+		formatter messageFormatter
+		opts      formatOptions
+		want      string
+	}{
+		{
+			name:      "GoDefault",
+			formatter: goFormat,
+			want:      "<6>2000-01-02T03:04:05Z localhost test[" + pid + "]: [t=\"2000-01-02T03:04:05.123Z\"] a message\n",
+		},
+		{
+			name:      "GoMillisecond",
+			formatter: goFormat,
+			opts:      formatOptions{TimePrecision: time.Millisecond, TimeLocation: loc},
+			want:      "<6>2000-01-02T04:04:05.123+01:00 localhost test[" + pid + "]: [t=\"2000-01-02T04:04:05.123+01:00\"] a message\n",
+		},
+		{
+			name:      "RFC3164Year",
+			formatter: rfc3164Format,
+			opts:      formatOptions{TimestampYear: true, TimePrecision: time.Microsecond},
+			want:      "<6>Jan  2 2000 03:04:05.123456 localhost test[" + pid + "]: [t=\"2000-01-02T03:04:05.123Z\"] a message\n",
+		},
+		{
+			name:      "RFC5424Second",
+			formatter: rfc5424Format,
+			opts:      formatOptions{TimePrecision: time.Second, SDID: "id@32473"},
+			want:      "<6>1 2000-01-02T03:04:05Z localhost test " + pid + " - [id@32473 t=\"2000-01-02T03:04:05.123Z\"] a message",
+		},
+		{
+			name:      "AttrTimeLayout",
+			formatter: localFormat,
+			opts:      formatOptions{TimeLocation: time.UTC, AttrTimeLayout: time.Kitchen},
+			want:      "<6>Jan  2 03:04:05 test[" + pid + "]: [t=\"3:04AM\"] a message\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := tc.opts
+			opts.Hostname = "localhost"
+			opts.Tag = "test"
+
+			r := slog.NewRecord(tm, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(slog.Time("t", tm))
+
+			if got := string(tc.formatter(context.Background(), nil, r, opts)); got != tc.want {
+				t.Errorf("formatter(ctx, nil, %v, %v) = %q; want %q", r, opts, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRFC5424Format_Header(t *testing.T) {
+	testCases := [...]struct {
+		name  string
+		opts  formatOptions
+		attrs []slog.Attr
+		want  string
+	}{
+		{
+			name:  "MsgIDAttr",
+			opts:  formatOptions{MsgID: func(slog.Record) string { return "ignored" }},
+			attrs: []slog.Attr{slog.String(MsgIDKey, "ID47"), slog.Int("a", 1)},
+			want:  `<14>1 2000-01-02T03:04:05.000000Z host app 42 ID47 [id@32473 a="1"] a message`,
+		},
+		{
+			name: "MsgIDFunc",
+			opts: formatOptions{MsgID: func(r slog.Record) string { return r.Level.String() }},
+			want: "<14>1 2000-01-02T03:04:05.000000Z host app 42 INFO - a message",
+		},
+		{
+			name: "Severity",
+			opts: formatOptions{Severity: func(slog.Record) Severity { return Notice }},
+			want: "<13>1 2000-01-02T03:04:05.000000Z host app 42 - - a message",
+		},
+		{
+			name:  "OnlyMsgID",
+			attrs: []slog.Attr{slog.String(MsgIDKey, "ID47")},
+			want:  "<14>1 2000-01-02T03:04:05.000000Z host app 42 ID47 - a message",
+		},
+		{
+			name:  "Sanitized",
+			opts:  formatOptions{Hostname: "my host", Tag: "äpp"},
+			attrs: []slog.Attr{slog.String(MsgIDKey, strings.Repeat("x", 40))},
+			want:  "<14>1 2000-01-02T03:04:05.000000Z my_host __pp 42 " + strings.Repeat("x", 32) + " - a message",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			opts := tc.opts
+			if opts.Hostname == "" {
+				opts.Hostname = "host"
+			}
+			if opts.Tag == "" {
+				opts.Tag = "app"
+			}
+			opts.Facility = User
+			opts.ProcID = "42"
+			opts.SDID = "id@32473"
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(tc.attrs...)
+
+			if got := string(rfc5424Format(context.Background(), nil, r, opts)); got != tc.want {
+				t.Errorf("rfc5424Format(ctx, nil, %v, %v) = %q; want %q", r, opts, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/rand"
+	"errors"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+const (
+	// gelfChunkHeaderLen is the length of the header of a GELF chunk holding
+	// the magic bytes, message ID, sequence number and sequence count.
+	gelfChunkHeaderLen = 12
+
+	// gelfMaxChunks is the maximum number of chunks a GELF message may be
+	// split into.
+	gelfMaxChunks = 128
+)
+
+// errGELFTooLarge is returned when a GELF message doesn't fit into the maximum
+// number of chunks.
+var errGELFTooLarge = errors.New("slogsyslog: GELF message too large")
+
+// gelfFormat outputs a message in the Graylog Extended Log Format version 1.1.
+// The first line of the message is the short message, while a message spanning
+// multiple lines is also written as the full message. The level follows the
+// syslog severities and the source and attributes are written as additional
+// fields with their keys prefixed by the groups joined by dots.
+func gelfFormat(_ context.Context, buf []byte, r slog.Record, opts formatOptions) []byte {
+	buf = append(buf, `{"version":"1.1"`...)
+	buf = appendJSONKey(buf, "host")
+	buf = appendJSONString(buf, opts.Hostname)
+
+	short, _, multiline := strings.Cut(r.Message, "\n")
+	buf = appendJSONKey(buf, "short_message")
+	buf = appendJSONString(buf, short)
+	if multiline {
+		buf = appendJSONKey(buf, "full_message")
+		buf = appendJSONString(buf, r.Message)
+	}
+
+	t := recordTime(r)
+	buf = appendJSONKey(buf, "timestamp")
+	buf = strconv.AppendInt(buf, t.Unix(), 10)
+	buf = append(buf, '.')
+	ms := t.Nanosecond() / 1e6
+	buf = append(buf, byte('0'+ms/100), byte('0'+ms/10%10), byte('0'+ms%10))
+
+	buf = appendJSONKey(buf, "level")
+	buf = strconv.AppendInt(buf, recordSeverity(r, opts), 10)
+
+	buf = appendGELFField(buf, "tag", slog.StringValue(opts.Tag))
+	flattenAttrs(r, opts, false, func(key string, v slog.Value) {
+		buf = appendGELFField(buf, key, attrTime(v, opts))
+	})
+
+	return append(buf, '}')
+}
+
+// appendGELFField adds an additional field. GELF only allows strings and
+// numbers, so every other value is written as a string.
+func appendGELFField(buf []byte, key string, v slog.Value) []byte {
+	// The key is prefixed by an underscore and may only contain word
+	// characters, dots and dashes, with _id being reserved.
+	key = "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '.' || r == '-' {
+			return r
+		}
+		return '_'
+	}, key)
+	if key == "_id" {
+		key = "__id"
+	}
+	buf = appendJSONKey(buf, key)
+
+	switch v.Kind() {
+	case slog.KindInt64, slog.KindUint64, slog.KindFloat64, slog.KindDuration:
+		return appendJSONValue(buf, v)
+	default:
+		return appendJSONString(buf, extValue(v))
+	}
+}
+
+// writeGELFChunks compresses the GELF message and writes it in datagrams of the
+// given maximum size. Messages larger than that are split into chunks.
+func writeGELFChunks(w io.Writer, b []byte, size int, c Compression) error {
+	switch c {
+	case CompressionGzip, CompressionZlib:
+		var (
+			buf bytes.Buffer
+			zw  io.WriteCloser
+		)
+		if c == CompressionGzip {
+			zw = gzip.NewWriter(&buf)
+		} else {
+			zw = zlib.NewWriter(&buf)
+		}
+		if _, err := zw.Write(b); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		b = buf.Bytes()
+	}
+
+	if len(b) <= size {
+		_, err := w.Write(b)
+		return err
+	}
+
+	data := size - gelfChunkHeaderLen
+	count := (len(b) + data - 1) / data
+	if count > gelfMaxChunks {
+		return errGELFTooLarge
+	}
+
+	chunk := make([]byte, 0, size)
+	chunk = append(chunk, 0x1e, 0x0f)
+	chunk = chunk[:10]
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return err
+	}
+	for i := 0; i < count; i++ {
+		end := min((i+1)*data, len(b))
+		chunk = append(chunk[:10], byte(i), byte(count))
+		chunk = append(chunk, b[i*data:end]...)
+		if _, err := w.Write(chunk); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,231 @@
+package slogsyslog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chunkWriter records every write as a separate datagram.
+type chunkWriter [][]byte
+
+func (w *chunkWriter) Write(b []byte) (int, error) {
+	*w = append(*w, bytes.Clone(b))
+	return len(b), nil
+}
+
+func TestGELFFormat(t *testing.T) {
+	opts := formatOptions{
+		Hostname: "localhost",
+		Tag:      "test",
+		Groups:   []groupOrAttrs{{attrs: []slog.Attr{slog.String("id", "x")}}, {group: "g"}},
+	}
+
+	r := slog.NewRecord(testTime.Add(123*time.Millisecond), slog.LevelWarn, "a message\nwith details", 0)
+	r.AddAttrs(slog.Int("n", 1), slog.Float64("f", 1.5), slog.Bool("b", true), slog.Group("h", slog.String("s", "v")))
+
+	got := gelfFormat(context.Background(), nil, r, opts)
+
+	var m map[string]any
+	if err := json.Unmarshal(got, &m); err != nil {
+		t.Fatalf("gelfFormat(ctx, nil, %v, %v) = %s: %s", r, opts, got, err)
+	}
+	want := map[string]any{
+		"version":       "1.1",
+		"host":          "localhost",
+		"short_message": "a message",
+		"full_message":  "a message\nwith details",
+		"timestamp":     946782245.123,
+		"level":         4.0,
+		"_tag":          "test",
+		"__id":          "x",
+		"_g.n":          1.0,
+		"_g.f":          1.5,
+		"_g.b":          "true",
+		"_g.h.s":        "v",
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("gelfFormat(ctx, nil, %v, %v) = %s; want %v", r, opts, got, want)
+	}
+}
+
+func TestWriteGELFChunks(t *testing.T) {
+	msg := []byte(strings.Repeat("0123456789", 30))
+
+	testCases := [...]struct {
+		name        string
+		compression Compression
+		size        int
+		chunks      int
+		decompress  func(io.Reader) (io.Reader, error)
+	}{
+		{
+			name:   "Single",
+			size:   1024,
+			chunks: 1,
+		},
+		{
+			name:   "Chunked",
+			size:   112,
+			chunks: 3,
+		},
+		{
+			name:        "Gzip",
+			compression: CompressionGzip,
+			size:        1024,
+			chunks:      1,
+			decompress:  func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		},
+		{
+			name:        "Zlib",
+			compression: CompressionZlib,
+			size:        1024,
+			chunks:      1,
+			decompress:  func(r io.Reader) (io.Reader, error) { return zlib.NewReader(r) },
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var w chunkWriter
+			if err := writeGELFChunks(&w, msg, tc.size, tc.compression); err != nil {
+				t.Fatalf("writeGELFChunks() = %v; want nil", err)
+			}
+			if len(w) != tc.chunks {
+				t.Fatalf("writeGELFChunks() wrote %d datagrams; want %d", len(w), tc.chunks)
+			}
+
+			var data []byte
+			if tc.chunks == 1 {
+				data = w[0]
+			} else {
+				for i, c := range w {
+					if len(c) > tc.size {
+						t.Errorf("Chunk[%d] is %d bytes long; want at most %d", i, len(c), tc.size)
+					}
+					if c[0] != 0x1e || c[1] != 0x0f || !bytes.Equal(c[2:10], w[0][2:10]) || c[10] != byte(i) || c[11] != byte(tc.chunks) {
+						t.Errorf("Chunk[%d] header = % x; want magic, message ID and %d/%d", i, c[:12], i, tc.chunks)
+					}
+					data = append(data, c[12:]...)
+				}
+			}
+
+			if tc.decompress != nil {
+				r, err := tc.decompress(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if data, err = io.ReadAll(r); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if !bytes.Equal(data, msg) {
+				t.Errorf("Message = %s; want %s", data, msg)
+			}
+		})
+	}
+}
+
+func TestWriteGELFChunks_TooLarge(t *testing.T) {
+	var w chunkWriter
+	msg := make([]byte, gelfMaxChunks*4+1)
+	if err := writeGELFChunks(&w, msg, gelfChunkHeaderLen+4, CompressionNone); err != errGELFTooLarge {
+		t.Errorf("writeGELFChunks() = %v; want %v", err, errGELFTooLarge)
+	}
+}
+
+func TestSyslogHandler_GELF(t *testing.T) {
+	t.Run("UDP", func(t *testing.T) {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer pc.Close()
+
+		h, err := New(&Options{Network: "udp", Address: pc.LocalAddr().String(), Format: FormatGELF, GELFCompression: CompressionGzip})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer h.Close()
+
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "a message", 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+
+		b := make([]byte, 64<<10)
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zr, err := gzip.NewReader(bytes.NewReader(b[:n]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(zr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(data, []byte(`"short_message":"a message"`)) {
+			t.Errorf("Message = %s; want short message %q", data, "a message")
+		}
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		h, err := New(&Options{Network: "tcp", Address: ln.Addr().String(), Format: FormatGELF})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer h.Close()
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		ctx := context.Background()
+		for _, msg := range [...]string{"first", "second"} {
+			if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)); err != nil {
+				t.Fatalf("Handle() = %v; want nil", err)
+			}
+		}
+
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		br := bufio.NewReader(conn)
+		for _, want := range [...]string{"first", "second"} {
+			data, err := br.ReadBytes(0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(data, []byte(`"short_message":"`+want+`"`)) {
+				t.Errorf("Message = %s; want short message %q", data, want)
+			}
+		}
+	})
+}
+
+func TestNew_GELFChunkSize(t *testing.T) {
+	if _, err := New(&Options{Network: "udp", Address: "127.0.0.1:12201", Format: FormatGELF, GELFChunkSize: gelfChunkHeaderLen}); err == nil {
+		t.Errorf("New() with a chunk size of %d = nil; want error", gelfChunkHeaderLen)
+	}
+}
@@ -3,11 +3,15 @@ package slogsyslog
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net"
 	"os"
 	"path/filepath"
-	"sync"
+	"slices"
+	"strconv"
 	"time"
 )
 
@@ -20,7 +24,19 @@ type Options struct {
 	// Level is the level at which we log at.
 	Level slog.Leveler
 
-	// Network protocol to use when connecting to a syslog server.
+	// ComponentLevels overrides Level for the handlers of components. A
+	// handler's component is the value of the ComponentKey attribute added
+	// outside of any group with WithAttrs or else the name of its first
+	// group. When nil, the levels are set from the LevelsEnv environment
+	// variable.
+	ComponentLevels *ComponentLevels
+
+	// ComponentKey is the key of the attribute naming the component of a
+	// handler. It defaults to DefaultComponentKey.
+	ComponentKey string
+
+	// Network protocol to use when connecting to a syslog server. The "relp"
+	// network sends messages over TCP with the RELP transport set by RELP.
 	Network string
 
 	// Address of the syslog server.
@@ -33,116 +49,514 @@ type Options struct {
 	// WriteTimeout is duration after which writing to a syslog server timeouts.
 	WriteTimeout time.Duration
 
-	// Facility with which we are logging.
+	// Facility with which we are logging. New rejects facilities other than
+	// the named ones, such as the unused codes 12 to 15.
 	Facility Facility
 
 	// Tag with which we are logging.
 	Tag string
+
+	// Format of the written messages.
+	Format Format
+
+	// SDID is the ID of the structured data element holding the attributes in
+	// the RFC 5424 format.
+	SDID string
+
+	// TLSConfig, when set, causes the handler to connect to a syslog server
+	// over TLS using the stream oriented network protocol from Network.
+	TLSConfig *tls.Config
+
+	// RELP sets the RELP transport of the "relp" network. The defaults are
+	// used when nil.
+	RELP *RELPOptions
+
+	// Spool enables spooling the messages that can't be written to a local
+	// directory, replaying them once the handler reconnects. New then
+	// succeeds even if the syslog server is unreachable.
+	Spool *SpoolOptions
+
+	// DeviceVendor is the vendor written in the CEF and LEEF headers.
+	DeviceVendor string
+
+	// DeviceProduct is the product written in the CEF and LEEF headers. It
+	// defaults to Tag.
+	DeviceProduct string
+
+	// DeviceVersion is the product version written in the CEF and LEEF headers.
+	DeviceVersion string
+
+	// ExtensionKeys maps attribute keys, with groups joined by dots, to the
+	// extension keys written in the CEF and LEEF formats, such as src, dst,
+	// suser or act. Attributes not in the map keep their key.
+	ExtensionKeys map[string]string
+
+	// GELFChunkSize is the maximum size of the datagrams GELF messages are
+	// split into on UDP. It defaults to [DefaultGELFChunkSize].
+	GELFChunkSize int
+
+	// GELFCompression is the compression of GELF messages sent on UDP.
+	GELFCompression Compression
+
+	// AttrPlacement is the placement of the attributes in the BSD formats.
+	AttrPlacement AttrPlacement
+
+	// AttrStyle is the syntax of the attributes in the BSD formats.
+	AttrStyle AttrStyle
+
+	// AttrSeparator separates the attributes in the BSD formats. It defaults
+	// to a space.
+	AttrSeparator string
+
+	// TimePrecision is the precision of the timestamp in the message header,
+	// being at most a microsecond. It defaults to a microsecond in the RFC
+	// 5424 format and to a second in the others.
+	TimePrecision time.Duration
+
+	// TimeLocation is the location of the timestamps in the message header and
+	// of time attributes, such as [time.UTC]. The time's own location is used
+	// when nil.
+	TimeLocation *time.Location
+
+	// TimestampYear causes the short timestamp of the local, RFC 3164, CEE,
+	// CEF and LEEF formats to include the year.
+	TimestampYear bool
+
+	// AttrTimeLayout is the [time.Time.Format] layout of time attributes. It
+	// isn't used by the CEE format, which follows [log/slog.JSONHandler].
+	AttrTimeLayout string
+
+	// Hostname is the host's name written in the message header. When empty,
+	// it is determined by HostnameMode.
+	Hostname string
+
+	// HostnameMode determines the host's name written in the message header
+	// unless Hostname is set.
+	HostnameMode HostnameMode
+
+	// ProcID is the process ID written in the message header. It defaults to
+	// the ID of the current process.
+	ProcID string
+
+	// MsgID returns the RFC 5424 message ID of the records without the
+	// [MsgIDKey] attribute.
+	MsgID func(slog.Record) string
+
+	// Severity returns the syslog severity written in the priority of the
+	// records. When nil, it is derived from the record's level.
+	Severity func(slog.Record) Severity
+
+	// Escape determines how control characters in the message and attributes
+	// of the BSD formats and in the message of the RFC 5424 format are
+	// escaped. Invalid UTF-8 is replaced unless escaping is turned off.
+	Escape EscapeMode
+
+	// BOM causes the message of the RFC 5424 format to start with the UTF-8
+	// byte order mark.
+	BOM bool
+
+	// SDNames determines how attribute keys that aren't valid RFC 5424
+	// SD-NAMEs are repaired in the bracketed style and the RFC 5424 format.
+	SDNames SDNameMode
+
+	// DuplicateKeys determines which attributes with the same key are written
+	// in the formats writing attributes as key-value pairs. Unless all of
+	// them are kept, the handler's attributes are formatted anew for every
+	// record.
+	DuplicateKeys DuplicatePolicy
+
+	// ValueEncoder encodes attribute values of kind Any, such as maps,
+	// structs and slices, in the formats writing attributes as key-value
+	// pairs. [JSONValue], [ExpandValue] and [RepeatValue] are the built-in
+	// encoders. If nil, values are written using their MarshalText method,
+	// the bytes of byte slices or as formatted by fmt.
+	ValueEncoder ValueEncoder
+
+	// ExpandErrors causes errors to be written as the attributes key.msg and
+	// key.type, followed by key.chain.N.msg and key.chain.N.type for every
+	// error in their chain, in the formats writing attributes as key-value
+	// pairs. Other values are left to ValueEncoder.
+	ExpandErrors bool
+
+	// ErrorStack adds the stack trace of expanded errors as key.stack, taken
+	// from the StackTrace method of an error in the chain or from formatting
+	// the error with %+v.
+	ErrorStack bool
+
+	// MaxErrorSize is the maximum size in bytes of the values an error expands
+	// to. Longer values are truncated and key.truncated is added. It defaults
+	// to DefaultMaxErrorSize.
+	MaxErrorSize int
+
+	// RepeatWindow enables suppressing identical consecutive records, with
+	// the same level, message and attributes, for the duration of the window
+	// after the first of them is written. A record reading "last message
+	// repeated N times" is written instead when a different record follows,
+	// when the window expires or when the handler is closed.
+	RepeatWindow time.Duration
+
+	// Sampling limits the records written by level. The rule of the highest
+	// level that isn't above a record's applies to it, while records below
+	// all the levels are never limited. Enabled reports false for a level
+	// whose records all share a budget that is exhausted, without counting
+	// anything as dropped since it may be called without logging.
+	Sampling map[slog.Level]SamplingRule
+
+	// SamplingReport is the interval of the warning reporting the number of
+	// records dropped by sampling by level, if any. It defaults to
+	// DefaultSamplingReport and reporting is turned off if negative. The
+	// warning isn't written if Level is above [log/slog.LevelWarn], but it is
+	// never dropped by sampling.
+	SamplingReport time.Duration
+
+	// OnError is called with the errors of the records that couldn't be
+	// written, including those returned by Handle, which [log/slog.Logger]
+	// discards, and those of the records written in the background.
+	//
+	// The hooks are called once the handler's locks are released, in New,
+	// Handle and Close or on the goroutine writing the summaries of repeated
+	// records and the sampling reports. They never run concurrently: the
+	// events occurring while a goroutine passes others to the hooks, such as
+	// when a hook logs through the handler, are passed by that goroutine
+	// once they return, so hooks logging don't recurse. A goroutine passes at
+	// most 1024 events at once, leaving the others for the next call to New,
+	// Handle or Close, and further events are discarded while as many are
+	// waiting.
+	OnError func(err error, r slog.Record)
+
+	// OnConnect is called with the server's address whenever a connection is
+	// established, including in New.
+	OnConnect func(addr net.Addr)
+
+	// OnDisconnect is called with the error causing a connection to be lost,
+	// or with nil when closed by Close.
+	OnDisconnect func(err error)
+
+	// OnDrop is called with the records that aren't written and why, after
+	// OnError is called with their error if any.
+	OnDrop func(reason DropReason, r slog.Record)
+
+	// Signing enables signing the messages as described in RFC 5848 in the
+	// RFC 5424 format.
+	Signing *SigningOptions
+
+	// Strict causes the handler to reject records, and New the header fields,
+	// that would otherwise have to be repaired with an error wrapping
+	// [ErrInvalid]. The lengths of the header fields and the attribute keys
+	// are only checked in the RFC 5424 format.
+	Strict bool
 }
 
 // SyslogHandler is a structured log [log/slog.Handler] implementation that
-// writes messages to a syslog server.
+// writes messages to a syslog server. Just like the syslog package from the
+// standard library, it reconnects and writes a message once more when writing
+// it fails. Records handled once it is closed fail with [net.ErrClosed].
 type SyslogHandler struct {
-	// mu protects the connection.
-	mu *sync.Mutex
-
 	// opts are options for this log.
 	opts Options
 
 	// formatter used for writing messages.
 	formatter messageFormatter
 
-	// hostname is the host's name we send when connected to a remote syslog
-	// server.
-	hostname string
-
-	// conn is the syslog connection.
-	conn net.Conn
+	// w is the syslog connection shared with derived handlers.
+	w *writer
 
 	// prefix value keys with group(s).
 	prefix []byte
 
 	// preformat is a pre-generated value of attributes.
 	preformat []byte
+
+	// goas are groups and attributes in the order they were added to the
+	// handler for formats nesting groups rather than prefixing keys.
+	goas []groupOrAttrs
+
+	// repeats suppresses repeated records for all the derived handlers if
+	// enabled.
+	repeats *repeater
+
+	// samples drops the records exceeding the sampling rules for all the
+	// derived handlers if enabled.
+	samples *sampler
+
+	// stats are the counters shared with the derived handlers.
+	stats *stats
+
+	// hooks are called with the events of all the derived handlers if any.
+	hooks *hooks
+
+	// component whose level overrides the handler's, if any.
+	component string
+}
+
+// groupOrAttrs is either a group or attributes added to a handler.
+type groupOrAttrs struct {
+	// group name if not empty.
+	group string
+
+	// attrs added when not a group.
+	attrs []slog.Attr
 }
 
 // New creates a new syslog slog [log/slog.Handler]. By default it will log at
-// [log/slog.LevelInfo] level to a UNIX datagram socket located at /dev/log.
+// [log/slog.LevelInfo] level to a UNIX datagram socket located at /dev/log
+// using the format matching the network.
 func New(opts *Options) (*SyslogHandler, error) {
-	h := &SyslogHandler{mu: &sync.Mutex{}}
+	h := &SyslogHandler{}
 	if opts != nil {
 		h.opts = *opts
 	}
 	if h.opts.Level == nil {
 		h.opts.Level = slog.LevelInfo
 	}
-	if h.opts.Network == "" {
-		h.opts.Network = "unixgram"
+	if h.opts.ComponentKey == "" {
+		h.opts.ComponentKey = DefaultComponentKey
 	}
-	if h.opts.Address == "" {
-		h.opts.Address = filepath.Join(string(filepath.Separator), "dev", "log")
+	if h.opts.ComponentLevels == nil {
+		h.opts.ComponentLevels = new(ComponentLevels)
+		if err := h.opts.ComponentLevels.Set(os.Getenv(LevelsEnv)); err != nil {
+			return nil, fmt.Errorf("%w in %s", err, LevelsEnv)
+		}
 	}
-	if h.opts.Facility <= 0 {
-		h.opts.Facility = Kern
+	h.opts.Network, h.opts.Address = h.opts.endpoint()
+	if !h.opts.Facility.valid() {
+		return nil, fmt.Errorf("slogsyslog: invalid facility %s", h.opts.Facility)
 	}
 	if h.opts.Tag == "" {
 		h.opts.Tag = os.Args[0]
 	}
+	if h.opts.SDID == "" {
+		h.opts.SDID = DefaultSDID
+	}
+	if h.opts.DeviceProduct == "" {
+		h.opts.DeviceProduct = h.opts.Tag
+	}
+	if h.opts.AttrSeparator == "" {
+		h.opts.AttrSeparator = " "
+	}
+	if h.opts.GELFChunkSize <= 0 {
+		h.opts.GELFChunkSize = DefaultGELFChunkSize
+	} else if h.opts.GELFChunkSize <= gelfChunkHeaderLen {
+		return nil, errors.New("slogsyslog: GELF chunk size too small")
+	}
 
-	// TODO: Support other syslog message formats.
-	var local bool
-	if h.opts.Network == "unixgram" || h.opts.Network == "unix" {
-		h.formatter = localFormat
-		local = true
-	} else {
+	if h.opts.ProcID == "" {
+		h.opts.ProcID = strconv.Itoa(os.Getpid())
+	}
+	if h.opts.MaxErrorSize <= 0 {
+		h.opts.MaxErrorSize = DefaultMaxErrorSize
+	}
+	if h.opts.ExpandErrors {
+		h.opts.ValueEncoder = errorEncoder(h.opts.ErrorStack, h.opts.MaxErrorSize, h.opts.ValueEncoder)
+	}
+
+	local := h.opts.Network == "unixgram" || h.opts.Network == "unix"
+
+	switch h.opts.Format {
+	case FormatGo:
 		h.formatter = goFormat
-		h.hostname, _ = os.Hostname()
-		local = false
+	case FormatLocal:
+		h.formatter = localFormat
+	case FormatRFC3164:
+		h.formatter = rfc3164Format
+	case FormatRFC5424:
+		h.formatter = rfc5424Format
+		// Structured data parameters have a syntax of their own.
+		h.opts.AttrStyle, h.opts.AttrSeparator = AttrStyleBracketed, " "
+	case FormatCEE:
+		h.formatter = ceeFormat
+	case FormatCEF:
+		h.formatter = cefFormat
+	case FormatLEEF:
+		h.formatter = leefFormat
+	case FormatGELF:
+		h.formatter = gelfFormat
+	default:
+		if local {
+			h.formatter = localFormat
+		} else {
+			h.formatter = goFormat
+		}
 	}
 
-	// TODO: Add TLS support.
-	conn, err := net.DialTimeout(h.opts.Network, h.opts.Address, h.opts.DialTimeout)
+	h.stats = new(stats)
+	h.hooks = newHooks(&h.opts)
+	h.w = newWriter(&h.opts, h.stats, h.hooks)
+	var sp *spool
+	if h.opts.Spool != nil {
+		var err error
+		if sp, err = openSpool(*h.opts.Spool, h.stats); err != nil {
+			return nil, err
+		}
+	}
+	err := h.w.connect()
+	if err != nil && sp != nil {
+		// The messages are spooled until the server is reachable.
+		h.stats.writeError(err)
+		err = nil
+	}
+	h.hooks.run()
 	if err != nil {
 		return nil, err
 	}
-	h.conn = conn
-
-	if h.hostname == "" && local {
-		h.hostname = conn.LocalAddr().String()
+	if sp != nil {
+		h.w.startSpool(sp)
+	}
+	if h.opts.RepeatWindow > 0 {
+		h.repeats = newRepeater(h.opts.RepeatWindow)
+	}
+	if len(h.opts.Sampling) > 0 {
+		samples, err := newSampler(h.opts.Sampling)
+		if err != nil {
+			h.w.close()
+			return nil, err
+		}
+		h.samples = samples
+
+		if h.opts.SamplingReport == 0 {
+			h.opts.SamplingReport = DefaultSamplingReport
+		}
+		if h.opts.SamplingReport > 0 {
+			h.samples.start(h, h.opts.SamplingReport)
+		}
+	}
+	if h.opts.Strict {
+		if err := validateHeader(h.opts.Format, *h.w.hostname.Load(), h.opts.Tag, h.opts.ProcID); err != nil {
+			h.Close()
+			return nil, err
+		}
+	}
+	if h.opts.Signing != nil {
+		if err := h.startSigning(); err != nil {
+			h.Close()
+			return nil, err
+		}
 	}
 
 	return h, nil
 }
 
 func (s *SyslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
-	return level >= s.opts.Level.Level()
+	return s.levelEnabled(level) && (s.samples == nil || !s.samples.exhausted(level))
+}
+
+// levelEnabled reports whether the level is at least the minimum level of the
+// handler's component.
+func (s *SyslogHandler) levelEnabled(level slog.Level) bool {
+	minLevel := s.opts.Level.Level()
+	if s.component != "" {
+		if l, ok := s.opts.ComponentLevels.Level(s.component); ok {
+			minLevel = l
+		}
+	}
+
+	return level >= minLevel
 }
 
 func (s *SyslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	defer s.hooks.run()
+
+	s.stats.records.Add(1)
+	if s.samples != nil && !s.samples.allow(r, s.goas) {
+		s.drop(DropSampled, r, nil)
+		return nil
+	}
+	if s.repeats != nil {
+		return s.repeats.handle(ctx, s, r)
+	}
+
+	return s.handle(ctx, r)
+}
+
+// handle formats and writes the record.
+func (s *SyslogHandler) handle(ctx context.Context, r slog.Record) error {
+	opts := s.formatOptions()
+	if s.opts.Strict {
+		if err := validateRecord(r, s.opts.Format, opts); err != nil {
+			s.drop(DropInvalid, r, err)
+			return err
+		}
+	}
+
 	bufp := allocBuf()
 	buf := *bufp
 
-	buf = s.formatter(ctx, buf, r, formatOptions{
-		AddSource: s.opts.AddSource,
-		Hostname:  s.hostname,
-		Facility:  s.opts.Facility,
-		Tag:       s.opts.Tag,
-		Prefix:    s.prefix,
-		Preformat: s.preformat,
-	})
+	buf = s.formatter(ctx, buf, r, opts)
 
-	s.mu.Lock()
-	if s.opts.WriteTimeout > 0 {
-		s.conn.SetWriteDeadline(time.Now().Add(s.opts.WriteTimeout))
+	var err error
+	if s.w.relp != nil && s.w.relp.waitsAck(r.Level) {
+		err = s.w.writeAcked(buf)
+	} else {
+		err = s.w.write(buf)
+	}
+	if err != nil {
+		s.drop(DropWriteError, r, err)
 	}
-	// FIXME: Don't write to a closed connection.
-	_, err := s.conn.Write(buf)
-	s.mu.Unlock()
 	*bufp = buf
 	freeBuf(bufp)
 	return err
 }
 
+// startSigning signs the messages written, formatting the blocks with the
+// handler's header.
+func (s *SyslogHandler) startSigning() error {
+	if s.opts.Format != FormatRFC5424 {
+		return errors.New("slogsyslog: signing requires the RFC 5424 format")
+	}
+
+	opts := s.formatOptions()
+	opts.AddSource, opts.MsgID, opts.Severity = false, nil, nil
+	signer, err := newSigner(*s.opts.Signing, func(e SDElement) []byte {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, "", 0)
+		r.AddAttrs(slog.Any(e.ID, e))
+		return rfc5424Format(context.Background(), nil, r, opts)
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.w.startSigning(signer)
+}
+
+// endpoint returns the network and address of the syslog server, which default
+// to the UNIX datagram socket located at /dev/log or to UDP port 12201 of the
+// local host in the GELF format.
+func (o *Options) endpoint() (network, address string) {
+	network, address = o.Network, o.Address
+	if o.Format == FormatGELF {
+		if network == "" {
+			network = "udp"
+		}
+		if address == "" {
+			address = "localhost:12201"
+		}
+	}
+	if network == "" {
+		network = "unixgram"
+	}
+	if address == "" {
+		address = filepath.Join(string(filepath.Separator), "dev", "log")
+	}
+
+	return network, address
+}
+
+// Levels returns the levels of components overriding the handler's level,
+// shared by all the handlers derived from the same root handler.
+func (s *SyslogHandler) Levels() *ComponentLevels {
+	return s.opts.ComponentLevels
+}
+
+// drop counts the record dropped for the reason and queues it and its error,
+// if any, for the hooks.
+func (s *SyslogHandler) drop(reason DropReason, r slog.Record, err error) {
+	s.stats.dropped[reason].Add(1)
+	if err != nil {
+		s.hooks.error(err, r)
+	}
+	s.hooks.drop(reason, r)
+}
+
 func (s *SyslogHandler) WithGroup(name string) slog.Handler {
 	if name == "" {
 		return s
@@ -153,14 +567,23 @@ func (s *SyslogHandler) WithGroup(name string) slog.Handler {
 	prefix = append(prefix, '.')
 	prefix = append(prefix, s.prefix...)
 
+	component := s.component
+	if component == "" {
+		component = name
+	}
+
 	return &SyslogHandler{
-		mu:        s.mu,
 		opts:      s.opts,
 		formatter: s.formatter,
-		hostname:  s.hostname,
-		conn:      s.conn,
+		w:         s.w,
 		prefix:    prefix,
 		preformat: s.preformat,
+		goas:      append(slices.Clip(s.goas), groupOrAttrs{group: name}),
+		repeats:   s.repeats,
+		samples:   s.samples,
+		stats:     s.stats,
+		hooks:     s.hooks,
+		component: component,
 	}
 }
 
@@ -169,27 +592,87 @@ func (s *SyslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 		return s
 	}
 
-	preformat := s.preformat
+	opts := s.formatOptions()
+	preformat := slices.Clip(s.preformat)
+	component := s.component
 	for _, a := range attrs {
-		preformat = appendAttr(preformat, s.prefix, a)
-		preformat = append(preformat, ' ')
+		if !isSDElement(a) || s.opts.Format != FormatRFC5424 {
+			// Elements are written on their own by the RFC 5424 format.
+			preformat = appendStyledAttr(preformat, s.prefix, a, opts)
+		}
+		if a.Key == s.opts.ComponentKey && len(s.prefix) == 0 {
+			component = resolveValue(a.Value).String()
+		}
 	}
 
 	return &SyslogHandler{
-		mu:        s.mu,
 		opts:      s.opts,
 		formatter: s.formatter,
-		hostname:  s.hostname,
-		conn:      s.conn,
+		w:         s.w,
 		prefix:    s.prefix,
 		preformat: preformat,
+		goas:      append(slices.Clip(s.goas), groupOrAttrs{attrs: slices.Clone(attrs)}),
+		repeats:   s.repeats,
+		samples:   s.samples,
+		stats:     s.stats,
+		hooks:     s.hooks,
+		component: component,
+	}
+}
+
+// formatOptions returns the options passed to the formatter.
+func (s *SyslogHandler) formatOptions() formatOptions {
+	return formatOptions{
+		AddSource: s.opts.AddSource,
+		Hostname:  *s.w.hostname.Load(),
+		Facility:  s.opts.Facility,
+		Tag:       s.opts.Tag,
+		ProcID:    s.opts.ProcID,
+		MsgID:     s.opts.MsgID,
+		Severity:  s.opts.Severity,
+		SDID:      s.opts.SDID,
+		Prefix:    s.prefix,
+		Preformat: s.preformat,
+		Groups:    s.goas,
+		Vendor:    s.opts.DeviceVendor,
+		Product:   s.opts.DeviceProduct,
+		Version:   s.opts.DeviceVersion,
+		ExtKeys:   s.opts.ExtensionKeys,
+
+		AttrPlacement: s.opts.AttrPlacement,
+		AttrStyle:     s.opts.AttrStyle,
+		AttrSeparator: s.opts.AttrSeparator,
+
+		TimePrecision:  s.opts.TimePrecision,
+		TimeLocation:   s.opts.TimeLocation,
+		TimestampYear:  s.opts.TimestampYear,
+		AttrTimeLayout: s.opts.AttrTimeLayout,
+
+		Escape:  s.opts.Escape,
+		BOM:     s.opts.BOM,
+		SDNames: s.opts.SDNames,
+
+		DuplicateKeys: s.opts.DuplicateKeys,
+		ValueEncoder:  s.opts.ValueEncoder,
 	}
 }
 
+// Close writes the summary of the suppressed repeated records and the report
+// of the records dropped by sampling, if any, closes the connection to the
+// syslog server and passes the waiting events to the hooks unless another
+// goroutine is passing them already. It affects all the handlers derived from
+// the same root handler.
 func (s *SyslogHandler) Close() error {
-	s.mu.Lock()
-	err := s.conn.Close()
-	s.mu.Unlock()
+	var errs []error
+	if s.samples != nil {
+		errs = append(errs, s.samples.close())
+	}
+	if s.repeats != nil {
+		errs = append(errs, s.repeats.close())
+	}
 
-	return err
+	errs = append(errs, s.w.close())
+	s.hooks.run()
+
+	return errors.Join(errs...)
 }
@@ -1,11 +1,56 @@
 package slogsyslog
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 )
 
+// newTestHandler creates a handler connected to a UNIX datagram socket in a
+// temporary directory. Use the slogsyslogtest package to inspect the written
+// messages.
+func newTestHandler(t *testing.T, opts *Options) *SyslogHandler {
+	t.Helper()
+
+	dir, err := os.MkdirTemp("", "slogsyslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	addr := filepath.Join(dir, "log.sock")
+	pc, err := net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	var o Options
+	if opts != nil {
+		o = *opts
+	}
+	o.Network, o.Address = "unixgram", addr
+
+	h, err := New(&o)
+	if err != nil {
+		t.Fatalf("New(%v) = %v; want nil", o, err)
+	}
+	t.Cleanup(func() { h.Close() })
+
+	return h
+}
+
 func TestNew(t *testing.T) {
+	if _, err := os.Stat("/dev/log"); err != nil {
+		t.Skip("/dev/log is not available")
+	}
+
 	f, err := New(nil)
 	if f == nil {
 		t.Fatal("New(<nil>) cannot return nil")
@@ -13,13 +58,53 @@ func TestNew(t *testing.T) {
 	if err != nil {
 		t.Errorf("New(<nil>) = %v; want nil", err)
 	}
+	f.Close()
 }
 
-func TestSyslogHandler_WithGroup(t *testing.T) {
-	s, _ := New(nil)
-	if s == nil {
-		t.Fatal("New(<nil>) cannot return nil")
+func TestNew_Defaults(t *testing.T) {
+	h := newTestHandler(t, nil)
+	if h.opts.Level != slog.LevelInfo {
+		t.Errorf("Options.Level = %v; want %v", h.opts.Level, slog.LevelInfo)
+	}
+	if h.opts.Facility != Kern {
+		t.Errorf("Options.Facility = %s; want %s", h.opts.Facility, Kern)
+	}
+	if h.opts.Tag != os.Args[0] {
+		t.Errorf("Options.Tag = %q; want %q", h.opts.Tag, os.Args[0])
 	}
+	if h.opts.ProcID != strconv.Itoa(os.Getpid()) {
+		t.Errorf("Options.ProcID = %q; want %d", h.opts.ProcID, os.Getpid())
+	}
+	if h.opts.DeviceProduct != h.opts.Tag {
+		t.Errorf("Options.DeviceProduct = %q; want %q", h.opts.DeviceProduct, h.opts.Tag)
+	}
+	if h.opts.MaxErrorSize != DefaultMaxErrorSize {
+		t.Errorf("Options.MaxErrorSize = %d; want %d", h.opts.MaxErrorSize, DefaultMaxErrorSize)
+	}
+}
+
+func TestNew_InvalidFacility(t *testing.T) {
+	for _, f := range [...]Facility{-8, 3, 12 << 3, 15 << 3, 24 << 3} {
+		if _, err := New(&Options{Network: "udp", Address: "127.0.0.1:514", Facility: f}); err == nil {
+			t.Errorf("New() with facility %v = nil; want error", f)
+		}
+	}
+}
+
+func TestSyslogHandler_Close(t *testing.T) {
+	h := newTestHandler(t, nil)
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+
+	l := h.WithGroup("foo").(*SyslogHandler)
+	if err := l.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "closed", 0)); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Handle() after Close() = %v; want %v", err, net.ErrClosed)
+	}
+}
+
+func TestSyslogHandler_WithGroup(t *testing.T) {
+	s := newTestHandler(t, nil)
 
 	s = s.WithGroup("foo").(*SyslogHandler)
 	if string(s.prefix) != "foo." {
@@ -33,10 +118,7 @@ func TestSyslogHandler_WithGroup(t *testing.T) {
 }
 
 func TestSyslogHandler_WithAttrs(t *testing.T) {
-	s, _ := New(nil)
-	if s == nil {
-		t.Fatal("New(<nil>) cannot return nil")
-	}
+	s := newTestHandler(t, nil)
 
 	s = s.WithAttrs([]slog.Attr{slog.String("foo", "bar")}).(*SyslogHandler)
 	if string(s.preformat) != "foo=\"bar\" " {
@@ -48,3 +130,12 @@ func TestSyslogHandler_WithAttrs(t *testing.T) {
 		t.Fatalf("*SyslogHandler.preformat = %s, want %s", s.preformat, "foo=\"bar\" bar=\"foo\" ")
 	}
 }
+
+func TestSyslogHandler_WithAttrs_Style(t *testing.T) {
+	s := newTestHandler(t, &Options{AttrStyle: AttrStyleLogfmt, AttrSeparator: ","})
+
+	s = s.WithAttrs([]slog.Attr{slog.String("foo", "bar baz"), slog.Int("n", 1)}).(*SyslogHandler)
+	if want := `foo="bar baz",n=1,`; string(s.preformat) != want {
+		t.Fatalf("*SyslogHandler.preformat = %s, want %s", s.preformat, want)
+	}
+}
@@ -2,12 +2,11 @@ package slogsyslog
 
 import (
 	"bytes"
-	"encoding"
-	"fmt"
 	"log/slog"
-	"reflect"
 	"strconv"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // levelToPriority turns slog level into syslog priority.
@@ -24,9 +23,33 @@ func levelToPriority(l slog.Level) int64 {
 	return lvl
 }
 
+// severityToLevel turns syslog severity back into slog level. Severities more
+// severe than an error are mapped to levels above [log/slog.LevelError], while
+// notice, not having its own level, lies between info and warning.
+func severityToLevel(s Severity) slog.Level {
+	switch {
+	case s <= Emerg:
+		return slog.LevelError + 12
+	case s == Alert:
+		return slog.LevelError + 8
+	case s == Crit:
+		return slog.LevelError + 4
+	case s == Err:
+		return slog.LevelError
+	case s == Warning:
+		return slog.LevelWarn
+	case s == Notice:
+		return slog.LevelInfo + 2
+	case s == Info:
+		return slog.LevelInfo
+	default:
+		return slog.LevelDebug
+	}
+}
+
 // appendAttr formats slog's attributes into syslog's structured data.
 func appendAttr(buf, prefix []byte, a slog.Attr) []byte {
-	a.Value = a.Value.Resolve()
+	a.Value = resolveValue(a.Value)
 	if a.Equal(slog.Attr{}) {
 		return buf
 	}
@@ -46,43 +69,11 @@ func appendAttr(buf, prefix []byte, a slog.Attr) []byte {
 	case slog.KindAny:
 		buf = appendKey(buf, prefix, a.Key)
 
-		val := a.Value.Any()
-		if src, ok := val.(*slog.Source); ok {
-			buf = append(buf, structuredEscape.Replace(src.File)...)
-			buf = append(buf, ':')
-			buf = strconv.AppendInt(buf, int64(src.Line), 10)
-			buf = append(buf, '"')
-			break
-		}
-
-		if tm, ok := val.(encoding.TextMarshaler); ok {
-			data, err := tm.MarshalText()
-			if err != nil {
-				buf = append(buf, '!', 'E', 'R', 'R', 'O', 'R', ':')
-				buf = append(buf, structuredEscape.Replace(fmt.Sprintf("%v", err))...)
-				buf = append(buf, '"')
-				break
-			}
-
-			buf = appendByteSlice(buf, data)
-			buf = append(buf, '"')
-			break
-		}
-
-		if bs, ok := val.([]byte); ok {
+		if bs, ok := byteSlice(a.Value.Any()); ok {
 			buf = appendByteSlice(buf, bs)
-			buf = append(buf, '"')
-			break
-		}
-
-		t := reflect.TypeOf(val)
-		if t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
-			buf = appendByteSlice(buf, reflect.ValueOf(val).Bytes())
-			buf = append(buf, '"')
-			break
+		} else {
+			buf = append(buf, structuredEscape.Replace(anyString(a.Value.Any()))...)
 		}
-
-		buf = append(buf, structuredEscape.Replace(fmt.Sprintf("%+v", val))...)
 		buf = append(buf, '"')
 	case slog.KindGroup:
 		attrs := a.Value.Group()
@@ -112,6 +103,57 @@ func appendAttr(buf, prefix []byte, a slog.Attr) []byte {
 	return buf
 }
 
+// appendStyledAttr adds the attribute, or each of the attributes of a group,
+// in the requested style followed by the separator.
+func appendStyledAttr(buf, prefix []byte, a slog.Attr, opts formatOptions) []byte {
+	flattenAttr(string(prefix), a, opts.ValueEncoder, true, 0, func(key string, v slog.Value) {
+		buf = appendStyledValue(buf, key, v, opts)
+	})
+
+	return buf
+}
+
+// appendStyledValue adds the key and value of a flattened attribute in the
+// requested style followed by the separator.
+func appendStyledValue(buf []byte, key string, v slog.Value, opts formatOptions) []byte {
+	v = attrTime(v, opts)
+	if opts.AttrStyle == AttrStyleLogfmt {
+		buf = appendLogfmtString(buf, key)
+		buf = append(buf, '=')
+		buf = appendLogfmtString(buf, extValue(v))
+	} else {
+		buf = appendAttr(buf, nil, slog.Attr{Key: sdName(key, opts.SDNames), Value: v})
+	}
+
+	return append(buf, opts.attrSeparator()...)
+}
+
+// appendLogfmtString adds the string quoting it only if needed, following the
+// same rules as the standard library's text handler.
+func appendLogfmtString(buf []byte, s string) []byte {
+	if needsQuoting(s) {
+		return strconv.AppendQuote(buf, s)
+	}
+
+	return append(buf, s...)
+}
+
+// needsQuoting reports whether the string is empty or contains spaces, equal
+// signs, quotes or characters that aren't printable.
+func needsQuoting(s string) bool {
+	if s == "" {
+		return true
+	}
+
+	for _, r := range s {
+		if r == ' ' || r == '=' || r == '"' || r == utf8.RuneError || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // appendKey adds attribute key to the syslog's structured data.
 func appendKey(buf, prefix []byte, key string) []byte {
 	buf = append(buf, prefix...)
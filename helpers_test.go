@@ -33,6 +33,16 @@ func TestLevelToPriority(t *testing.T) {
 			level: slog.LevelError,
 			want:  3,
 		},
+		{
+			name:  "Notice",
+			level: slog.LevelInfo + 2,
+			want:  4,
+		},
+		{
+			name:  "Emergency",
+			level: slog.LevelError + 12,
+			want:  3,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -47,6 +57,59 @@ func TestLevelToPriority(t *testing.T) {
 	}
 }
 
+func TestSeverityToLevel(t *testing.T) {
+	testCases := [...]struct {
+		name     string
+		severity Severity
+		want     slog.Level
+	}{
+		{
+			name:     "Emerg",
+			severity: Emerg,
+			want:     slog.LevelError + 12,
+		},
+		{
+			name:     "Err",
+			severity: Err,
+			want:     slog.LevelError,
+		},
+		{
+			name:     "Warning",
+			severity: Warning,
+			want:     slog.LevelWarn,
+		},
+		{
+			name:     "Notice",
+			severity: Notice,
+			want:     slog.LevelInfo + 2,
+		},
+		{
+			name:     "Info",
+			severity: Info,
+			want:     slog.LevelInfo,
+		},
+		{
+			name:     "Debug",
+			severity: Debug,
+			want:     slog.LevelDebug,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if lvl := severityToLevel(tc.severity); lvl != tc.want {
+				t.Errorf("severityToLevel(%s) = %s; want %s", tc.severity, lvl, tc.want)
+			}
+			if pri := levelToPriority(severityToLevel(tc.severity)); tc.severity >= Err && tc.severity != Notice && pri != int64(tc.severity) {
+				t.Errorf("levelToPriority(severityToLevel(%s)) = %d; want %d", tc.severity, pri, tc.severity)
+			}
+		})
+	}
+}
+
 func TestAppendAttr(t *testing.T) {
 	testCases := [...]struct {
 		name string
@@ -155,3 +218,25 @@ func TestAppendByteSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestNeedsQuoting(t *testing.T) {
+	testCases := [...]struct {
+		s    string
+		want bool
+	}{
+		{"", true},
+		{"abc", false},
+		{"a b", true},
+		{"a=b", true},
+		{`a"b`, true},
+		{"a\nb", true},
+		{"a\xffb", true},
+		{"ünïcode", false},
+	}
+
+	for _, tc := range testCases {
+		if got := needsQuoting(tc.s); got != tc.want {
+			t.Errorf("needsQuoting(%q) = %t; want %t", tc.s, got, tc.want)
+		}
+	}
+}
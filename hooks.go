@@ -0,0 +1,150 @@
+package slogsyslog
+
+import (
+	"log/slog"
+	"net"
+	"sync"
+)
+
+// maxHookEvents is the maximum number of events waiting to be passed to the
+// hooks. Further events are discarded.
+const maxHookEvents = 1024
+
+// hooks passes the events of the handlers sharing it to the hooks in the
+// options. Events are queued wherever they occur, possibly while locks are
+// held, and passed to the hooks by run once they are released.
+type hooks struct {
+	onError      func(err error, r slog.Record)
+	onConnect    func(addr net.Addr)
+	onDisconnect func(err error)
+	onDrop       func(reason DropReason, r slog.Record)
+
+	// mu protects the queue of events and delivering, which is set while the
+	// events are passed to the hooks, so that they don't run concurrently or
+	// recursively.
+	mu         sync.Mutex
+	queue      []func()
+	delivering bool
+}
+
+// newHooks returns the hooks of the options or nil if there are none.
+func newHooks(opts *Options) *hooks {
+	if opts.OnError == nil && opts.OnConnect == nil && opts.OnDisconnect == nil && opts.OnDrop == nil {
+		return nil
+	}
+
+	return &hooks{
+		onError:      opts.OnError,
+		onConnect:    opts.OnConnect,
+		onDisconnect: opts.OnDisconnect,
+		onDrop:       opts.OnDrop,
+	}
+}
+
+// error queues the error of the record.
+func (hk *hooks) error(err error, r slog.Record) {
+	if hk == nil || hk.onError == nil {
+		return
+	}
+
+	r = r.Clone()
+	hk.enqueue(func() { hk.onError(err, r) })
+}
+
+// connect queues the connection to the address.
+func (hk *hooks) connect(addr net.Addr) {
+	if hk == nil || hk.onConnect == nil {
+		return
+	}
+
+	hk.enqueue(func() { hk.onConnect(addr) })
+}
+
+// disconnect queues the loss of the connection because of the error.
+func (hk *hooks) disconnect(err error) {
+	if hk == nil || hk.onDisconnect == nil {
+		return
+	}
+
+	hk.enqueue(func() { hk.onDisconnect(err) })
+}
+
+// drop queues the record dropped for the reason.
+func (hk *hooks) drop(reason DropReason, r slog.Record) {
+	if hk == nil || hk.onDrop == nil {
+		return
+	}
+
+	r = r.Clone()
+	hk.enqueue(func() { hk.onDrop(reason, r) })
+}
+
+// enqueue adds the event to the queue unless it is full.
+func (hk *hooks) enqueue(event func()) {
+	hk.mu.Lock()
+	defer hk.mu.Unlock()
+
+	if len(hk.queue) < maxHookEvents {
+		hk.queue = append(hk.queue, event)
+	}
+}
+
+// run passes the queued events to the hooks unless they are already being
+// passed to them, in which case the delivering call passes the new events too,
+// including those caused by the hooks themselves, once the hooks return. At
+// most maxHookEvents events are passed at once, the others being left for the
+// next run, so that hooks logging keep returning. The caller must not hold any
+// of the handler's locks.
+func (hk *hooks) run() {
+	if hk == nil {
+		return
+	}
+
+	hk.mu.Lock()
+	if hk.delivering {
+		hk.mu.Unlock()
+		return
+	}
+	hk.delivering = true
+	hk.mu.Unlock()
+
+	// next stops delivering once there are no more events, which is done
+	// here if a hook panics.
+	done := false
+	defer func() {
+		if !done {
+			hk.mu.Lock()
+			hk.delivering = false
+			hk.mu.Unlock()
+		}
+	}()
+
+	for n := 0; ; {
+		queue := hk.next(n)
+		if queue == nil {
+			done = true
+			return
+		}
+		for _, event := range queue {
+			event()
+		}
+		n += len(queue)
+	}
+}
+
+// next returns the queued events to pass to the hooks after n of them were
+// passed, or nil, no longer delivering, if there are none or too many were
+// passed already.
+func (hk *hooks) next(n int) []func() {
+	hk.mu.Lock()
+	defer hk.mu.Unlock()
+
+	if len(hk.queue) == 0 || n >= maxHookEvents {
+		hk.delivering = false
+		return nil
+	}
+	queue := hk.queue
+	hk.queue = nil
+
+	return queue
+}
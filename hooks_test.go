@@ -0,0 +1,142 @@
+package slogsyslog
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyslogHandler_Hooks(t *testing.T) {
+	dir, err := os.MkdirTemp("", "slogsyslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	addr := filepath.Join(dir, "log.sock")
+	pc, err := net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []string
+	h, err := New(&Options{
+		Network: "unixgram",
+		Address: addr,
+		OnError: func(err error, r slog.Record) {
+			events = append(events, "error "+r.Message)
+		},
+		OnConnect: func(addr net.Addr) {
+			events = append(events, "connect "+filepath.Base(addr.String()))
+		},
+		OnDisconnect: func(err error) {
+			events = append(events, fmt.Sprintf("disconnect %t", err != nil))
+		},
+		OnDrop: func(reason DropReason, r slog.Record) {
+			events = append(events, "drop "+reason.String()+" "+r.Message)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	pc.Close()
+	os.Remove(addr)
+	h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0))
+
+	if pc, err = net.ListenPacket("unixgram", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "found", 0))
+	h.Close()
+
+	want := []string{
+		"connect log.sock",
+		"disconnect true",
+		"error lost",
+		"drop write_error lost",
+		"connect log.sock",
+		"disconnect false",
+	}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("Events = %q; want %q", events, want)
+	}
+}
+
+func TestSyslogHandler_Hooks_Recursion(t *testing.T) {
+	var (
+		h            *SyslogHandler
+		calls, depth int
+	)
+	h = newTestHandler(t, &Options{
+		Strict: true,
+		OnError: func(err error, r slog.Record) {
+			calls++
+			depth++
+			defer func() { depth-- }()
+			if depth > 1 {
+				t.Errorf("OnError depth = %d; want 1", depth)
+			}
+			if calls < 3 {
+				// The record is invalid too.
+				h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "hook\x00", 0))
+			}
+		},
+	})
+
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "a\x00", 0)); err == nil {
+		t.Fatal("Handle() = nil; want error")
+	}
+	if calls != 3 {
+		t.Errorf("OnError calls after Handle() = %d; want 3", calls)
+	}
+	if n := len(h.hooks.queue); n != 0 {
+		t.Errorf("Queued events = %d; want 0", n)
+	}
+}
+
+func TestSyslogHandler_Hooks_Concurrent(t *testing.T) {
+	var (
+		calls   atomic.Int64
+		entered = make(chan struct{})
+		release = make(chan struct{})
+	)
+	h := newTestHandler(t, &Options{
+		Strict: true,
+		OnError: func(err error, r slog.Record) {
+			if calls.Add(1) == 1 {
+				close(entered)
+				<-release
+			}
+		},
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "first\x00", 0))
+	}()
+	<-entered
+
+	// The event is passed to the hook by the goroutine already running it.
+	h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "second\x00", 0))
+	if n := calls.Load(); n != 1 {
+		t.Errorf("OnError calls while running = %d; want 1", n)
+	}
+	close(release)
+	<-done
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("OnError calls = %d; want 2", n)
+	}
+}
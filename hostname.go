@@ -0,0 +1,77 @@
+package slogsyslog
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// resolveHostname returns the host's name written in the message header as
+// requested by the options. The connection, if any, is used to find the IP
+// address of the outgoing interface.
+func resolveHostname(opts *Options, conn net.Conn) string {
+	if opts.Hostname != "" {
+		return opts.Hostname
+	}
+
+	hostname, _ := os.Hostname()
+	switch opts.HostnameMode {
+	case HostnameShort:
+		hostname, _, _ = strings.Cut(hostname, ".")
+	case HostnameFQDN:
+		hostname = fqdn(hostname)
+	case HostnameIP:
+		// There is no interface for UNIX sockets, so we stick to the host's
+		// name then, as we do when not connected yet.
+		if ip, ok := interfaceIP(conn); ok {
+			return ip
+		}
+	}
+
+	return hostname
+}
+
+// interfaceIP returns the IP address of the outgoing interface of the
+// connection, if any.
+func interfaceIP(conn net.Conn) (string, bool) {
+	if conn == nil {
+		return "", false
+	}
+
+	switch addr := conn.LocalAddr().(type) {
+	case *net.UDPAddr:
+		return addr.IP.String(), true
+	case *net.TCPAddr:
+		return addr.IP.String(), true
+	default:
+		return "", false
+	}
+}
+
+// fqdn returns the fully qualified domain name of the host as known by the
+// local resolver or the host's name itself if there is none.
+func fqdn(hostname string) string {
+	if strings.Contains(hostname, ".") {
+		return hostname
+	}
+
+	addrs, err := net.LookupHost(hostname)
+	if err != nil {
+		return hostname
+	}
+
+	for _, addr := range addrs {
+		names, err := net.LookupAddr(addr)
+		if err != nil {
+			continue
+		}
+		for _, name := range names {
+			name = strings.TrimSuffix(name, ".")
+			if strings.HasPrefix(name, hostname+".") {
+				return name
+			}
+		}
+	}
+
+	return hostname
+}
@@ -0,0 +1,89 @@
+package slogsyslog
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResolveHostname(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	conn, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	hostname, _ := os.Hostname()
+	short, _, _ := strings.Cut(hostname, ".")
+
+	testCases := [...]struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "OS",
+			want: hostname,
+		},
+		{
+			name: "Fixed",
+			opts: Options{Hostname: "fixed.example.com", HostnameMode: HostnameIP},
+			want: "fixed.example.com",
+		},
+		{
+			name: "Short",
+			opts: Options{HostnameMode: HostnameShort},
+			want: short,
+		},
+		{
+			name: "IP",
+			opts: Options{HostnameMode: HostnameIP},
+			want: "127.0.0.1",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := resolveHostname(&tc.opts, conn); got != tc.want {
+			t.Errorf("%s: resolveHostname(%v, conn) = %q; want %q", tc.name, tc.opts, got, tc.want)
+		}
+	}
+}
+
+func TestResolveHostname_FQDN(t *testing.T) {
+	hostname, _ := os.Hostname()
+
+	opts := Options{HostnameMode: HostnameFQDN}
+	if got := resolveHostname(&opts, nil); !strings.HasPrefix(got, hostname) {
+		t.Errorf("resolveHostname(%v, <nil>) = %q; want prefix %q", opts, got, hostname)
+	}
+}
+
+func TestWriter_HostnameIP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	opts := &Options{Network: "udp", Address: pc.LocalAddr().String(), HostnameMode: HostnameIP}
+	w := newWriter(opts, new(stats), nil)
+	hostname, _ := os.Hostname()
+	if got := *w.hostname.Load(); got != hostname {
+		t.Errorf("Hostname before connecting = %q; want %q", got, hostname)
+	}
+
+	if err := w.connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+	if got := *w.hostname.Load(); got != "127.0.0.1" {
+		t.Errorf("Hostname once connected = %q; want %q", got, "127.0.0.1")
+	}
+}
@@ -0,0 +1,41 @@
+// Package cliutil implements parsing of command line arguments shared by the
+// commands of the module.
+package cliutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+// ParsePriority parses the priority given either as a number or as a facility
+// and a level name separated by a dot. The facility defaults to user if
+// omitted.
+func ParsePriority(s string) (slogsyslog.Facility, slogsyslog.Severity, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > 191 {
+			return 0, 0, fmt.Errorf("invalid priority %q", s)
+		}
+		return slogsyslog.Facility(n &^ 7), slogsyslog.Severity(n & 7), nil
+	}
+
+	facility := slogsyslog.User
+	name, level, ok := strings.Cut(s, ".")
+	if ok {
+		var err error
+		if facility, err = slogsyslog.ParseFacility(name); err != nil {
+			return 0, 0, err
+		}
+	} else {
+		level = name
+	}
+
+	severity, err := slogsyslog.ParseSeverity(level)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return facility, severity, nil
+}
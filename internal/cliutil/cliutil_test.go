@@ -0,0 +1,64 @@
+package cliutil
+
+import (
+	"testing"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+func TestParsePriority(t *testing.T) {
+	testCases := [...]struct {
+		name     string
+		priority string
+		facility slogsyslog.Facility
+		severity slogsyslog.Severity
+		err      bool
+	}{
+		{
+			name:     "Full",
+			priority: "local0.err",
+			facility: slogsyslog.Local0,
+			severity: slogsyslog.Err,
+		},
+		{
+			name:     "LevelOnly",
+			priority: "DEBUG",
+			facility: slogsyslog.User,
+			severity: slogsyslog.Debug,
+		},
+		{
+			name:     "Numeric",
+			priority: "30",
+			facility: slogsyslog.Daemon,
+			severity: slogsyslog.Info,
+		},
+		{
+			name:     "UnknownFacility",
+			priority: "foo.info",
+			err:      true,
+		},
+		{
+			name:     "UnknownLevel",
+			priority: "user.foo",
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			f, s, err := ParsePriority(tc.priority)
+			if tc.err {
+				if err == nil {
+					t.Errorf("ParsePriority(%q) = <nil>; want error", tc.priority)
+				}
+				return
+			}
+			if err != nil || f != tc.facility || s != tc.severity {
+				t.Errorf("ParsePriority(%q) = %s, %s, %v; want %s, %s, <nil>", tc.priority, f, s, err, tc.facility, tc.severity)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package slogsyslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// duration is a [time.Duration] decoded from JSON as a string parsed by
+// [time.ParseDuration] or as a number of nanoseconds.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return json.Unmarshal(b, (*time.Duration)(d))
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("slogsyslog: invalid duration %q", s)
+	}
+	*d = duration(v)
+
+	return nil
+}
+
+// UnmarshalJSON decodes the options from a JSON object whose keys are the
+// names of the fields, matched case-insensitively. Durations are strings parsed
+// by [time.ParseDuration], such as "2s", Level is a level name parsed by
+// [log/slog.Level.UnmarshalText], such as "warn", and Facility and Format are
+// parsed by [ParseFacility] and [ParseFormat] and ComponentLevels is a string
+// set by [ComponentLevels.Set]. The fields that are functions can't be decoded.
+func (o *Options) UnmarshalJSON(b []byte) error {
+	// options has the fields of Options but not its methods.
+	type options Options
+	v := struct {
+		*options
+		Level          *slog.Level
+		DialTimeout    duration
+		WriteTimeout   duration
+		TimePrecision  duration
+		RepeatWindow   duration
+		SamplingReport duration
+	}{
+		options:        (*options)(o),
+		DialTimeout:    duration(o.DialTimeout),
+		WriteTimeout:   duration(o.WriteTimeout),
+		TimePrecision:  duration(o.TimePrecision),
+		RepeatWindow:   duration(o.RepeatWindow),
+		SamplingReport: duration(o.SamplingReport),
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	if v.Level != nil {
+		o.Level = *v.Level
+	}
+	o.DialTimeout = time.Duration(v.DialTimeout)
+	o.WriteTimeout = time.Duration(v.WriteTimeout)
+	o.TimePrecision = time.Duration(v.TimePrecision)
+	o.RepeatWindow = time.Duration(v.RepeatWindow)
+	o.SamplingReport = time.Duration(v.SamplingReport)
+
+	return nil
+}
+
+// UnmarshalJSON decodes the rule from a JSON object whose keys are the names
+// of the fields, matched case-insensitively, with Period being a string parsed
+// by [time.ParseDuration].
+func (r *SamplingRule) UnmarshalJSON(b []byte) error {
+	// rule has the fields of SamplingRule but not its methods.
+	type rule SamplingRule
+	v := struct {
+		*rule
+		Period duration
+	}{rule: (*rule)(r), Period: duration(r.Period)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	r.Period = time.Duration(v.Period)
+
+	return nil
+}
+
+// UnmarshalJSON decodes the spool options from a JSON object whose keys are
+// the names of the fields, matched case-insensitively, with MaxAge and
+// RetryInterval being strings parsed by [time.ParseDuration].
+func (o *SpoolOptions) UnmarshalJSON(b []byte) error {
+	// options has the fields of SpoolOptions but not its methods.
+	type options SpoolOptions
+	v := struct {
+		*options
+		MaxAge        duration
+		RetryInterval duration
+	}{options: (*options)(o), MaxAge: duration(o.MaxAge), RetryInterval: duration(o.RetryInterval)}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.MaxAge = time.Duration(v.MaxAge)
+	o.RetryInterval = time.Duration(v.RetryInterval)
+
+	return nil
+}
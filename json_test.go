@@ -0,0 +1,72 @@
+package slogsyslog
+
+import (
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestOptions_UnmarshalJSON(t *testing.T) {
+	b := []byte(`{
+		"network": "tcp",
+		"address": "logs.internal:6514",
+		"facility": "local3",
+		"tag": "api",
+		"format": "RFC5424",
+		"level": "warn",
+		"dialTimeout": "2s",
+		"writeTimeout": 1000000000,
+		"componentLevels": "db=debug",
+		"sampling": {"info": {"first": 10, "period": "1m"}},
+		"repeatWindow": "10s",
+		"spool": {"dir": "/var/spool/api", "maxSize": 1048576, "maxAge": "1h"}
+	}`)
+
+	o := Options{SamplingReport: time.Hour}
+	if err := json.Unmarshal(b, &o); err != nil {
+		t.Fatal(err)
+	}
+
+	if level, ok := o.ComponentLevels.Level("db"); !ok || level != slog.LevelDebug {
+		t.Errorf("ComponentLevels.Level(db) = %v, %t; want %v, true", level, ok, slog.LevelDebug)
+	}
+	o.ComponentLevels = nil
+
+	want := Options{
+		Network:        "tcp",
+		Address:        "logs.internal:6514",
+		Facility:       Local3,
+		Tag:            "api",
+		Format:         FormatRFC5424,
+		Level:          slog.LevelWarn,
+		DialTimeout:    2 * time.Second,
+		WriteTimeout:   time.Second,
+		Sampling:       map[slog.Level]SamplingRule{slog.LevelInfo: {First: 10, Period: time.Minute}},
+		RepeatWindow:   10 * time.Second,
+		SamplingReport: time.Hour,
+		Spool:          &SpoolOptions{Dir: "/var/spool/api", MaxSize: 1 << 20, MaxAge: time.Hour},
+	}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("json.Unmarshal() = %+v; want %+v", o, want)
+	}
+}
+
+func TestOptions_UnmarshalJSON_Invalid(t *testing.T) {
+	for _, b := range [...]string{
+		`{"facility": "local8"}`,
+		`{"facility": 19}`,
+		`{"format": "xml"}`,
+		`{"level": "loud"}`,
+		`{"dialTimeout": "2"}`,
+		`{"sampling": {"info": {"period": "often"}}}`,
+		`{"componentLevels": "db"}`,
+		`{"spool": {"maxAge": "soon"}}`,
+	} {
+		var o Options
+		if err := json.Unmarshal([]byte(b), &o); err == nil {
+			t.Errorf("json.Unmarshal(%s) = nil; want error", b)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package slogsyslog
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultComponentKey is the default key of the attribute naming the
+	// component of a handler.
+	DefaultComponentKey = "logger"
+
+	// LevelsEnv is the environment variable holding the levels of components,
+	// such as "db=debug,http=warn".
+	LevelsEnv = "SLOG_SYSLOG_LEVELS"
+)
+
+// ComponentLevels are the levels of components overriding the handler's level.
+// They can be changed at any time and are safe for concurrent use. It is also a
+// [flag.Value] setting levels from a comma separated list of component=level
+// pairs. The zero value has no levels.
+type ComponentLevels struct {
+	// mu serializes changes to levels, which is replaced rather than
+	// modified so that it can be read without locking.
+	mu     sync.Mutex
+	levels atomic.Pointer[map[string]slog.Level]
+}
+
+// Level returns the level of the component, if any.
+func (l *ComponentLevels) Level(component string) (slog.Level, bool) {
+	levels := l.levels.Load()
+	if levels == nil {
+		return 0, false
+	}
+
+	level, ok := (*levels)[component]
+	return level, ok
+}
+
+// SetLevel sets the level of the component.
+func (l *ComponentLevels) SetLevel(component string, level slog.Level) {
+	l.update(func(levels map[string]slog.Level) {
+		levels[component] = level
+	})
+}
+
+// ResetLevel removes the level of the component, which then logs at the
+// handler's level.
+func (l *ComponentLevels) ResetLevel(component string) {
+	l.update(func(levels map[string]slog.Level) {
+		delete(levels, component)
+	})
+}
+
+// update replaces the levels with a copy changed by f.
+func (l *ComponentLevels) update(f func(map[string]slog.Level)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	levels := make(map[string]slog.Level)
+	if old := l.levels.Load(); old != nil {
+		for component, level := range *old {
+			levels[component] = level
+		}
+	}
+	f(levels)
+	l.levels.Store(&levels)
+}
+
+// Set sets the levels of a comma separated list of component=level pairs, such
+// as "db=debug,http=warn". The levels are parsed by
+// [log/slog.Level.UnmarshalText]. None are set if any of them is invalid.
+func (l *ComponentLevels) Set(s string) error {
+	levels := make(map[string]slog.Level)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		component, name, ok := strings.Cut(pair, "=")
+		component = strings.TrimSpace(component)
+		if !ok || component == "" {
+			return fmt.Errorf("slogsyslog: invalid component level %q", pair)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+			return fmt.Errorf("slogsyslog: invalid level of component %q: %w", component, err)
+		}
+		levels[component] = level
+	}
+
+	l.update(func(m map[string]slog.Level) {
+		for component, level := range levels {
+			m[component] = level
+		}
+	})
+
+	return nil
+}
+
+// MarshalText returns the levels as String does.
+func (l *ComponentLevels) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+// UnmarshalText sets the levels as Set does.
+func (l *ComponentLevels) UnmarshalText(text []byte) error {
+	return l.Set(string(text))
+}
+
+// String returns the levels as a comma separated list of component=level pairs
+// sorted by component.
+func (l *ComponentLevels) String() string {
+	if l == nil {
+		return ""
+	}
+	levels := l.levels.Load()
+	if levels == nil {
+		return ""
+	}
+
+	components := make([]string, 0, len(*levels))
+	for component := range *levels {
+		components = append(components, component)
+	}
+	slices.Sort(components)
+
+	var b strings.Builder
+	for i, component := range components {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(component)
+		b.WriteByte('=')
+		b.WriteString((*levels)[component].String())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+)
+
+func TestComponentLevels_Set(t *testing.T) {
+	testCases := [...]struct {
+		name    string
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{name: "Empty", spec: "", want: ""},
+		{name: "Levels", spec: "http=warn, db=DEBUG,auth=info+2", want: "auth=INFO+2,db=DEBUG,http=WARN"},
+		{name: "TrailingComma", spec: "db=error,", want: "db=ERROR"},
+		{name: "MissingLevel", spec: "db", wantErr: true},
+		{name: "MissingComponent", spec: "=debug", wantErr: true},
+		{name: "InvalidLevel", spec: "db=debug,http=loud", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var l ComponentLevels
+			err := l.Set(tc.spec)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Set(%q) = %v; want error %t", tc.spec, err, tc.wantErr)
+			}
+			if got := l.String(); got != tc.want {
+				t.Errorf("String() = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestComponentLevels_SetLevel(t *testing.T) {
+	var l ComponentLevels
+	if _, ok := l.Level("db"); ok {
+		t.Error("Level(db) of the zero value = true; want false")
+	}
+
+	l.SetLevel("db", slog.LevelDebug)
+	if level, ok := l.Level("db"); !ok || level != slog.LevelDebug {
+		t.Errorf("Level(db) = %v, %t; want %v, true", level, ok, slog.LevelDebug)
+	}
+
+	l.ResetLevel("db")
+	if _, ok := l.Level("db"); ok {
+		t.Error("Level(db) after ResetLevel(db) = true; want false")
+	}
+}
+
+func TestSyslogHandler_ComponentLevels(t *testing.T) {
+	t.Setenv(LevelsEnv, "db=debug,http=warn")
+
+	h := newTestHandler(t, nil)
+	ctx := context.Background()
+	for _, tc := range [...]struct {
+		name  string
+		h     slog.Handler
+		level slog.Level
+		want  bool
+	}{
+		{"Root", h, slog.LevelDebug, false},
+		{"Root", h, slog.LevelInfo, true},
+		{"Group", h.WithGroup("db"), slog.LevelDebug, true},
+		{"NestedGroup", h.WithGroup("http").WithGroup("db"), slog.LevelInfo, false},
+		{"Attr", h.WithAttrs([]slog.Attr{slog.String("logger", "db")}), slog.LevelDebug, true},
+		{"AttrThenGroup", h.WithAttrs([]slog.Attr{slog.String("logger", "http")}).WithGroup("db"), slog.LevelInfo, false},
+		{"AttrInGroup", h.WithGroup("http").WithAttrs([]slog.Attr{slog.String("logger", "db")}), slog.LevelInfo, false},
+		{"Unknown", h.WithGroup("cache"), slog.LevelInfo, true},
+	} {
+		if got := tc.h.Enabled(ctx, tc.level); got != tc.want {
+			t.Errorf("%s: Enabled(%v) = %t; want %t", tc.name, tc.level, got, tc.want)
+		}
+	}
+
+	d := h.WithGroup("http")
+	h.Levels().SetLevel("http", slog.LevelDebug)
+	if !d.Enabled(ctx, slog.LevelDebug) {
+		t.Errorf("Enabled(%v) after SetLevel(http, %[1]v) = false; want true", slog.LevelDebug)
+	}
+}
+
+func TestNew_LevelsEnv(t *testing.T) {
+	t.Setenv(LevelsEnv, "db=loud")
+
+	if _, err := New(&Options{Network: "udp", Address: "127.0.0.1:514"}); err == nil {
+		t.Errorf("New() with %s=db=loud = nil; want error", LevelsEnv)
+	}
+}
@@ -0,0 +1,434 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// ErrMalformed is returned when a syslog message cannot be parsed.
+var ErrMalformed = errors.New("slogsyslog: malformed message")
+
+// SDParam is a RFC 5424 structured data parameter.
+type SDParam struct {
+	// Name of the parameter.
+	Name string
+
+	// Value of the parameter with all escaping removed.
+	Value string
+}
+
+// SDElement is a RFC 5424 structured data element. Attributes written in
+// square brackets in front of the message by the handler's BSD formats are
+// parsed as an element without an ID.
+type SDElement struct {
+	// ID of the element.
+	ID string
+
+	// Params are the element's parameters in the order they were sent.
+	Params []SDParam
+}
+
+// LogValue returns the element's parameters as a group. It allows the element
+// to be logged as an attribute. The RFC 5424 format writes such attributes of
+// a record or a handler as separate structured data elements, while the others
+// write them as any other group.
+func (e SDElement) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, len(e.Params))
+	for _, p := range e.Params {
+		attrs = append(attrs, slog.String(p.Name, p.Value))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// Message is a parsed syslog message.
+type Message struct {
+	// Facility of the message.
+	Facility Facility
+
+	// Severity of the message.
+	Severity Severity
+
+	// Version of the RFC 5424 protocol. It is zero for RFC 3164 messages.
+	Version int
+
+	// Timestamp of the message. It is zero if the message does not carry one.
+	Timestamp time.Time
+
+	// Hostname of the sender.
+	Hostname string
+
+	// AppName is the application name or, for RFC 3164 messages, the tag.
+	AppName string
+
+	// ProcID is the process ID of the sender.
+	ProcID string
+
+	// MsgID is the type of the message.
+	MsgID string
+
+	// StructuredData are the message's structured data elements.
+	StructuredData []SDElement
+
+	// Text is the actual log message.
+	Text string
+}
+
+// ParseMessage parses a single RFC 5424 or RFC 3164 syslog message, including
+// the RFC 3339 timestamp variant written by the syslog package from the
+// standard library. Any trailing new line is removed.
+func ParseMessage(b []byte) (*Message, error) {
+	b = bytes.TrimRight(b, "\r\n\x00")
+
+	m := &Message{}
+	b, err := parsePriority(m, b)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(b, []byte{'1', ' '}) {
+		m.Version = 1
+		err = parseRFC5424(m, b[2:])
+	} else {
+		err = parseRFC3164(m, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Record creates a [log/slog.Record] from the message. The severity is mapped
+// back to a slog level, the header fields are added as attributes under
+// [FacilityKey], [HostnameKey], [AppNameKey], [ProcIDKey] and [MsgIDKey], while
+// structured data elements become groups named by their IDs.
+func (m *Message) Record() slog.Record {
+	t := m.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	r := slog.NewRecord(t, severityToLevel(m.Severity), m.Text, 0)
+	r.AddAttrs(slog.String(FacilityKey, m.Facility.String()))
+	if m.Hostname != "" {
+		r.AddAttrs(slog.String(HostnameKey, m.Hostname))
+	}
+	if m.AppName != "" {
+		r.AddAttrs(slog.String(AppNameKey, m.AppName))
+	}
+	if m.ProcID != "" {
+		r.AddAttrs(slog.String(ProcIDKey, m.ProcID))
+	}
+	if m.MsgID != "" {
+		r.AddAttrs(slog.String(MsgIDKey, m.MsgID))
+	}
+
+	for _, e := range m.StructuredData {
+		if e.ID == "" {
+			r.AddAttrs(e.LogValue().Group()...)
+		} else {
+			r.AddAttrs(slog.Attr{Key: e.ID, Value: e.LogValue()})
+		}
+	}
+
+	return r
+}
+
+// ScanMessages is a split function for a [bufio.Scanner] that returns each
+// syslog message read from a stream. It supports both octet counting and new
+// line delimited framing as described in RFC 6587.
+func ScanMessages(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if data[0] >= '1' && data[0] <= '9' {
+		i := bytes.IndexByte(data, ' ')
+		if i < 0 {
+			if atEOF || len(data) > 10 {
+				return 0, nil, fmt.Errorf("%w: invalid octet count", ErrMalformed)
+			}
+			return 0, nil, nil
+		}
+
+		n, err := strconv.Atoi(string(data[:i]))
+		if err != nil {
+			return 0, nil, fmt.Errorf("%w: invalid octet count", ErrMalformed)
+		}
+		if len(data) < i+1+n {
+			if atEOF {
+				return 0, nil, fmt.Errorf("%w: truncated message", ErrMalformed)
+			}
+			return 0, nil, nil
+		}
+
+		return i + 1 + n, data[i+1 : i+1+n], nil
+	}
+
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+// parsePriority parses the message's priority and returns the rest of it.
+func parsePriority(m *Message, b []byte) ([]byte, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return nil, fmt.Errorf("%w: missing priority", ErrMalformed)
+	}
+
+	end := bytes.IndexByte(b[:min(len(b), 5)], '>')
+	if end < 2 {
+		return nil, fmt.Errorf("%w: invalid priority", ErrMalformed)
+	}
+
+	pri, err := strconv.Atoi(string(b[1:end]))
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, fmt.Errorf("%w: invalid priority", ErrMalformed)
+	}
+	m.Facility = Facility(pri &^ 7)
+	m.Severity = Severity(pri & 7)
+
+	return b[end+1:], nil
+}
+
+// parseRFC5424 parses the header, structured data and the message of a RFC
+// 5424 message following the version.
+func parseRFC5424(m *Message, b []byte) error {
+	var (
+		field []byte
+		ok    bool
+	)
+
+	if field, b, ok = nextField(b); !ok {
+		return fmt.Errorf("%w: missing timestamp", ErrMalformed)
+	}
+	if string(field) != "-" {
+		t, err := time.Parse(time.RFC3339Nano, string(field))
+		if err != nil {
+			return fmt.Errorf("%w: invalid timestamp", ErrMalformed)
+		}
+		m.Timestamp = t
+	}
+
+	for _, f := range [...]struct {
+		name string
+		dst  *string
+	}{
+		{"hostname", &m.Hostname},
+		{"app name", &m.AppName},
+		{"process ID", &m.ProcID},
+		{"message ID", &m.MsgID},
+	} {
+		if field, b, ok = nextField(b); !ok {
+			return fmt.Errorf("%w: missing %s", ErrMalformed, f.name)
+		}
+		if string(field) != "-" {
+			*f.dst = string(field)
+		}
+	}
+
+	switch {
+	case len(b) == 0:
+		return fmt.Errorf("%w: missing structured data", ErrMalformed)
+	case b[0] == '-':
+		b = b[1:]
+	default:
+		for len(b) > 0 && b[0] == '[' {
+			var (
+				e   SDElement
+				err error
+			)
+			if e, b, err = parseSDElement(b, true); err != nil {
+				return err
+			}
+			m.StructuredData = append(m.StructuredData, e)
+		}
+	}
+
+	if len(b) > 0 {
+		if b[0] != ' ' {
+			return fmt.Errorf("%w: invalid structured data", ErrMalformed)
+		}
+		b = bytes.TrimPrefix(b[1:], []byte("\xef\xbb\xbf"))
+	}
+	m.Text = string(b)
+
+	return nil
+}
+
+// parseRFC3164 parses the header and the message of a RFC 3164 message
+// following the priority. Since the format is loosely defined, anything we
+// can't make sense of is considered to be a part of the message.
+func parseRFC3164(m *Message, b []byte) error {
+	// Messages written by the syslog package from the standard library carry a
+	// RFC 3339 timestamp while the others use a short one without the year.
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, string(b[:i])); err == nil {
+			m.Timestamp = t
+			b = b[i+1:]
+		}
+	}
+	if m.Timestamp.IsZero() {
+		if t, rest, ok := parseStamp(b); ok {
+			m.Timestamp = t
+			b = rest
+		}
+	}
+	if m.Timestamp.IsZero() {
+		m.Text = string(b)
+		return nil
+	}
+
+	// Hostname is optional and, when missing, the tag immediately follows the
+	// timestamp. Tags, unlike hostnames, are terminated by a colon or a PID.
+	if field, rest, ok := nextField(b); ok && bytes.IndexAny(field, "[:") < 0 {
+		m.Hostname = string(field)
+		b = rest
+	}
+
+	if end := bytes.IndexAny(b, "[: "); end > 0 {
+		tag, rest := b[:end], b[end:]
+
+		var pid []byte
+		if rest[0] == '[' {
+			if i := bytes.IndexByte(rest, ']'); i > 0 {
+				pid, rest = rest[1:i], rest[i+1:]
+			} else {
+				rest = nil
+			}
+		}
+
+		if len(rest) > 0 && rest[0] == ':' {
+			m.AppName = string(tag)
+			m.ProcID = string(pid)
+			b = bytes.TrimPrefix(rest[1:], []byte{' '})
+		}
+	}
+
+	if len(b) > 0 && b[0] == '[' {
+		if e, rest, err := parseSDElement(b, false); err == nil && (len(rest) == 0 || rest[0] == ' ') {
+			m.StructuredData = append(m.StructuredData, e)
+			b = bytes.TrimPrefix(rest, []byte{' '})
+		}
+	}
+	m.Text = string(b)
+
+	return nil
+}
+
+// parseSDElement parses a single structured data element and returns the rest
+// of the message following it.
+func parseSDElement(b []byte, withID bool) (SDElement, []byte, error) {
+	var e SDElement
+
+	b = b[1:]
+	if withID {
+		end := bytes.IndexAny(b, " ]")
+		if end <= 0 {
+			return e, nil, fmt.Errorf("%w: invalid structured data ID", ErrMalformed)
+		}
+		e.ID = string(b[:end])
+		b = b[end:]
+	}
+
+	for {
+		if len(b) == 0 {
+			return e, nil, fmt.Errorf("%w: unterminated structured data", ErrMalformed)
+		}
+		if b[0] == ']' {
+			return e, b[1:], nil
+		}
+		if withID || len(e.Params) > 0 {
+			if b[0] != ' ' {
+				return e, nil, fmt.Errorf("%w: invalid structured data", ErrMalformed)
+			}
+			b = b[1:]
+		}
+
+		// Keys written by the handler's BSD formats aren't escaped and may
+		// contain any character, so we look for the start of the value.
+		end := bytes.Index(b, []byte{'=', '"'})
+		if end <= 0 {
+			return e, nil, fmt.Errorf("%w: invalid structured data parameter", ErrMalformed)
+		}
+		p := SDParam{Name: string(b[:end])}
+		b = b[end+2:]
+
+		val := make([]byte, 0, len(b))
+		i := 0
+		for ; i < len(b) && b[i] != '"'; i++ {
+			if b[i] == '\\' && i+1 < len(b) && (b[i+1] == '"' || b[i+1] == '\\' || b[i+1] == ']') {
+				i++
+			}
+			val = append(val, b[i])
+		}
+		if i == len(b) {
+			return e, nil, fmt.Errorf("%w: unterminated structured data parameter", ErrMalformed)
+		}
+		p.Value = string(val)
+		e.Params = append(e.Params, p)
+		b = b[i+1:]
+	}
+}
+
+// nextField returns the space delimited field at the start of b and the rest
+// following it.
+func nextField(b []byte) (field, rest []byte, ok bool) {
+	i := bytes.IndexByte(b, ' ')
+	if i <= 0 {
+		return nil, nil, false
+	}
+
+	return b[:i], b[i+1:], true
+}
+
+// parseStamp parses a short timestamp, such as [time.Stamp], optionally
+// followed by the year before the time and a fraction of a second after it.
+func parseStamp(b []byte) (t time.Time, rest []byte, ok bool) {
+	for _, layout := range [...]string{stampYear, time.Stamp} {
+		end := len(layout)
+		if len(b) <= end {
+			continue
+		}
+		if b[end] == '.' {
+			for end++; end < len(b) && b[end] >= '0' && b[end] <= '9'; end++ {
+			}
+		}
+		if end >= len(b) || b[end] != ' ' {
+			continue
+		}
+
+		t, err := time.ParseInLocation(layout, string(b[:end]), time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == time.Stamp {
+			t = stampTime(t, time.Now())
+		}
+
+		return t, b[end+1:], true
+	}
+
+	return time.Time{}, b, false
+}
+
+// stampTime completes the time parsed from a timestamp without the year, such
+// as [time.Stamp], with the year nearest to now in local time.
+func stampTime(t, now time.Time) time.Time {
+	t = time.Date(now.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.Local)
+	if t.After(now.AddDate(0, 1, 0)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+
+	return t
+}
@@ -0,0 +1,231 @@
+package slogsyslog
+
+import (
+	"bufio"
+	"errors"
+	"log/slog"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMessage(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		raw  string
+		want *Message
+	}{
+		{
+			name: "RFC5424",
+			raw:  "<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut=\"3\" eventSource=\"Appl\\\"ication\\]\"][examplePriority@32473 class=\"high\"] \xef\xbb\xbfAn application event\n",
+			want: &Message{
+				Facility:  Local4,
+				Severity:  Notice,
+				Version:   1,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.UTC),
+				Hostname:  "mymachine.example.com",
+				AppName:   "evntslog",
+				MsgID:     "ID47",
+				StructuredData: []SDElement{
+					{ID: "exampleSDID@32473", Params: []SDParam{{"iut", "3"}, {"eventSource", `Appl"ication]`}}},
+					{ID: "examplePriority@32473", Params: []SDParam{{"class", "high"}}},
+				},
+				Text: "An application event",
+			},
+		},
+		{
+			name: "RFC5424Nil",
+			raw:  "<34>1 - - - - - -",
+			want: &Message{
+				Facility: Auth,
+				Severity: Crit,
+				Version:  1,
+			},
+		},
+		{
+			name: "Go",
+			raw:  "<30>2000-01-02T03:04:05Z localhost test[12]: [a=\"1\" x = y=\"qu\\\"o\\]\"] a message\n",
+			want: &Message{
+				Facility:  Daemon,
+				Severity:  Info,
+				Timestamp: time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC),
+				Hostname:  "localhost",
+				AppName:   "test",
+				ProcID:    "12",
+				StructuredData: []SDElement{
+					{Params: []SDParam{{"a", "1"}, {"x = y", `qu"o]`}}},
+				},
+				Text: "a message",
+			},
+		},
+		{
+			name: "Local",
+			raw:  "<3>Jan  2 03:04:05 test[12]: [bad attrs a message\n",
+			want: &Message{
+				Severity:  Err,
+				Timestamp: stampTime(time.Date(0, 1, 2, 3, 4, 5, 0, time.UTC), time.Now()),
+				AppName:   "test",
+				ProcID:    "12",
+				Text:      "[bad attrs a message",
+			},
+		},
+		{
+			name: "RFC3164",
+			raw:  "<34>Oct 11 22:14:15 mymachine su: 'su root' failed for lonvick on /dev/pts/8",
+			want: &Message{
+				Facility:  Auth,
+				Severity:  Crit,
+				Timestamp: stampTime(time.Date(0, 10, 11, 22, 14, 15, 0, time.UTC), time.Now()),
+				Hostname:  "mymachine",
+				AppName:   "su",
+				Text:      "'su root' failed for lonvick on /dev/pts/8",
+			},
+		},
+		{
+			name: "RFC3164YearFraction",
+			raw:  "<34>Oct 11 2003 22:14:15.003 mymachine su: a message",
+			want: &Message{
+				Facility:  Auth,
+				Severity:  Crit,
+				Timestamp: time.Date(2003, 10, 11, 22, 14, 15, 3000000, time.Local),
+				Hostname:  "mymachine",
+				AppName:   "su",
+				Text:      "a message",
+			},
+		},
+		{
+			name: "RFC3164NoHeader",
+			raw:  "<13>just a message",
+			want: &Message{
+				Facility: User,
+				Severity: Notice,
+				Text:     "just a message",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := ParseMessage([]byte(tc.raw))
+			if err != nil {
+				t.Fatalf("ParseMessage(%q) = %v; want nil", tc.raw, err)
+			}
+			if !m.Timestamp.Equal(tc.want.Timestamp) {
+				t.Errorf("ParseMessage(%q).Timestamp = %v; want %v", tc.raw, m.Timestamp, tc.want.Timestamp)
+			}
+			m.Timestamp = tc.want.Timestamp
+			if !reflect.DeepEqual(m, tc.want) {
+				t.Errorf("ParseMessage(%q) = %+v; want %+v", tc.raw, m, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseMessage_Malformed(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		raw  string
+	}{
+		{
+			name: "Empty",
+			raw:  "",
+		},
+		{
+			name: "NoPriority",
+			raw:  "Oct 11 22:14:15 mymachine su: failed",
+		},
+		{
+			name: "PriorityOutOfRange",
+			raw:  "<192>Oct 11 22:14:15 mymachine su: failed",
+		},
+		{
+			name: "RFC5424Truncated",
+			raw:  "<34>1 2003-10-11T22:14:15.003Z host app",
+		},
+		{
+			name: "RFC5424Timestamp",
+			raw:  "<34>1 yesterday host app - - - message",
+		},
+		{
+			name: "RFC5424StructuredData",
+			raw:  "<34>1 - - - - - [id a=\"1\" message",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ParseMessage([]byte(tc.raw)); !errors.Is(err, ErrMalformed) {
+				t.Errorf("ParseMessage(%q) = %v; want %v", tc.raw, err, ErrMalformed)
+			}
+		})
+	}
+}
+
+func TestMessage_Record(t *testing.T) {
+	m := &Message{
+		Facility:  Local0,
+		Severity:  Warning,
+		Timestamp: testTime,
+		Hostname:  "host",
+		AppName:   "app",
+		ProcID:    "12",
+		StructuredData: []SDElement{
+			{Params: []SDParam{{"a", "1"}}},
+			{ID: "id@32473", Params: []SDParam{{"b", "2"}}},
+		},
+		Text: "a message",
+	}
+
+	r := m.Record()
+	if !r.Time.Equal(testTime) || r.Level != slog.LevelWarn || r.Message != "a message" {
+		t.Errorf("Message.Record() = %v %v %q; want %v %v %q", r.Time, r.Level, r.Message, testTime, slog.LevelWarn, "a message")
+	}
+
+	var attrs []string
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a.String())
+		return true
+	})
+	want := []string{"facility=Local0", "hostname=host", "app_name=app", "procid=12", "a=1", "id@32473=[b=2]"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("Message.Record() attributes = %v; want %v", attrs, want)
+	}
+}
+
+func TestScanMessages(t *testing.T) {
+	in := "<1>first\n10 <2>second\n<3>third\n\n<4>last"
+	want := []string{"<1>first", "<2>second\n", "<3>third", "", "<4>last"}
+
+	sc := bufio.NewScanner(strings.NewReader(in))
+	sc.Split(ScanMessages)
+
+	var got []string
+	for sc.Scan() {
+		got = append(got, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		t.Fatalf("Scanner.Err() = %v; want nil", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ScanMessages(%q) = %q; want %q", in, got, want)
+	}
+}
+
+func TestScanMessages_Truncated(t *testing.T) {
+	in := "20 <1>first"
+
+	sc := bufio.NewScanner(strings.NewReader(in))
+	sc.Split(ScanMessages)
+	for sc.Scan() {
+	}
+	if err := sc.Err(); !errors.Is(err, ErrMalformed) {
+		t.Errorf("Scanner.Err() = %v; want %v", err, ErrMalformed)
+	}
+}
@@ -0,0 +1,279 @@
+package slogsyslog
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// ReceiverOptions sets the syslog receiver options.
+type ReceiverOptions struct {
+	// Network protocol to listen on. Both stream ("tcp", "unix") and datagram
+	// ("udp", "unixgram") oriented protocols are supported.
+	Network string
+
+	// Address to listen on.
+	Address string
+
+	// TLSConfig, when set, causes the receiver to accept TLS connections over
+	// the stream oriented network protocol from Network.
+	TLSConfig *tls.Config
+
+	// MaxMessageSize is the maximum size of a received message in bytes.
+	MaxMessageSize int
+}
+
+// Receiver is a syslog server that turns received messages into
+// [log/slog.Record] and passes them to a [log/slog.Handler]. It is the
+// reverse of the [SyslogHandler].
+type Receiver struct {
+	// handler receives the converted records.
+	handler slog.Handler
+
+	// opts are options for this receiver.
+	opts ReceiverOptions
+
+	// ctx is passed to the handler and cancelled once the receiver is closed.
+	ctx context.Context
+
+	// cancel cancels the context.
+	cancel context.CancelFunc
+
+	// wg tracks the listeners and connections being served.
+	wg sync.WaitGroup
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// listeners are listeners and packet connections being served.
+	listeners map[io.Closer]struct{}
+
+	// conns are currently accepted connections.
+	conns map[net.Conn]struct{}
+
+	// closed indicates whether the receiver was closed.
+	closed bool
+}
+
+// NewReceiver creates a new syslog receiver passing records to h. By default it
+// listens on UDP port 514 and accepts messages of up to 64 KiB.
+func NewReceiver(h slog.Handler, opts *ReceiverOptions) *Receiver {
+	r := &Receiver{
+		handler:   h,
+		listeners: make(map[io.Closer]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+	if opts != nil {
+		r.opts = *opts
+	}
+	if r.opts.Network == "" {
+		r.opts.Network = "udp"
+	}
+	if r.opts.Address == "" {
+		r.opts.Address = ":514"
+	}
+	if r.opts.MaxMessageSize <= 0 {
+		r.opts.MaxMessageSize = 64 << 10
+	}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	return r
+}
+
+// ListenAndServe listens on the network address from the options and serves
+// incoming messages until the receiver is closed.
+func (r *Receiver) ListenAndServe() error {
+	switch r.opts.Network {
+	case "udp", "udp4", "udp6", "unixgram":
+		pc, err := net.ListenPacket(r.opts.Network, r.opts.Address)
+		if err != nil {
+			return err
+		}
+		return r.ServePacket(pc)
+	default:
+		ln, err := net.Listen(r.opts.Network, r.opts.Address)
+		if err != nil {
+			return err
+		}
+		if r.opts.TLSConfig != nil {
+			ln = tls.NewListener(ln, r.opts.TLSConfig)
+		}
+		return r.Serve(ln)
+	}
+}
+
+// Serve accepts connections on the stream oriented listener and serves their
+// messages until the receiver is closed. Both octet counting and new line
+// delimited framing are supported. Temporary errors accepting connections are
+// retried after a delay growing up to a second, like [net/http.Server.Serve]
+// does. The listener is closed on return.
+func (r *Receiver) Serve(ln net.Listener) error {
+	if !r.track(ln) {
+		ln.Close()
+		return nil
+	}
+	defer r.untrack(ln)
+
+	var delay time.Duration
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			if r.isClosed() {
+				return nil
+			}
+			var ne net.Error
+			if !errors.As(err, &ne) || !ne.Temporary() {
+				return err
+			}
+			delay = min(max(2*delay, 5*time.Millisecond), time.Second)
+			select {
+			case <-time.After(delay):
+			case <-r.ctx.Done():
+				return nil
+			}
+			continue
+		}
+		delay = 0
+
+		r.mu.Lock()
+		if r.closed {
+			r.mu.Unlock()
+			c.Close()
+			return nil
+		}
+		r.conns[c] = struct{}{}
+		r.wg.Add(1)
+		r.mu.Unlock()
+
+		go r.serveConn(c)
+	}
+}
+
+// ServePacket reads a message from each datagram received on the connection
+// until the receiver is closed. The connection is closed on return.
+func (r *Receiver) ServePacket(pc net.PacketConn) error {
+	if !r.track(pc) {
+		pc.Close()
+		return nil
+	}
+	defer r.untrack(pc)
+
+	buf := make([]byte, r.opts.MaxMessageSize)
+	for {
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			if r.isClosed() {
+				return nil
+			}
+			return err
+		}
+		r.handle(buf[:n])
+	}
+}
+
+// Close stops the receiver by closing all of its listeners and connections. It
+// waits for the messages being handled to finish, including those read by
+// ServePacket, and for Serve and ServePacket to return.
+func (r *Receiver) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.cancel()
+
+	var err error
+	for l := range r.listeners {
+		if cerr := l.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	for c := range r.conns {
+		c.Close()
+	}
+	r.mu.Unlock()
+
+	r.wg.Wait()
+
+	return err
+}
+
+// serveConn reads messages from an accepted connection until it is closed.
+func (r *Receiver) serveConn(c net.Conn) {
+	defer r.wg.Done()
+	defer func() {
+		r.mu.Lock()
+		delete(r.conns, c)
+		r.mu.Unlock()
+		c.Close()
+	}()
+
+	sc := bufio.NewScanner(c)
+	sc.Buffer(make([]byte, 0, 4096), r.opts.MaxMessageSize)
+	sc.Split(ScanMessages)
+	for sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			r.handle(sc.Bytes())
+		}
+	}
+}
+
+// handle turns a received message into a record and passes it to the handler.
+// Messages that can't be parsed are passed along as they are with the user
+// facility and notice severity as RFC 3164 suggests.
+func (r *Receiver) handle(b []byte) {
+	m, err := ParseMessage(b)
+	if err != nil {
+		m = &Message{
+			Facility:  User,
+			Severity:  Notice,
+			Timestamp: time.Now(),
+			Text:      string(b),
+		}
+	}
+
+	rec := m.Record()
+	if !r.handler.Enabled(r.ctx, rec.Level) {
+		return
+	}
+	r.handler.Handle(r.ctx, rec)
+}
+
+// track registers a listener to be closed with the receiver and waited for
+// until it's untracked. It returns false if the receiver was already closed.
+func (r *Receiver) track(l io.Closer) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return false
+	}
+	r.listeners[l] = struct{}{}
+	r.wg.Add(1)
+
+	return true
+}
+
+// untrack closes a listener and unregisters it.
+func (r *Receiver) untrack(l io.Closer) {
+	r.mu.Lock()
+	delete(r.listeners, l)
+	r.mu.Unlock()
+	l.Close()
+	r.wg.Done()
+}
+
+// isClosed reports whether the receiver was closed.
+func (r *Receiver) isClosed() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.closed
+}
@@ -0,0 +1,208 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordHandler is a [log/slog.Handler] that collects handled records.
+type recordHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+	notify  chan struct{}
+}
+
+func newRecordHandler() *recordHandler {
+	return &recordHandler{notify: make(chan struct{}, 100)}
+}
+
+func (h *recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	h.records = append(h.records, r)
+	h.mu.Unlock()
+	h.notify <- struct{}{}
+
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+// wait waits for n records to be handled.
+func (h *recordHandler) wait(t *testing.T, n int) []slog.Record {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-h.notify:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %d record(s), got %d", n, i)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return append([]slog.Record(nil), h.records...)
+}
+
+// recordAttrs returns the record's attributes as a map of strings.
+func recordAttrs(r slog.Record) map[string]string {
+	attrs := make(map[string]string)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value.String()
+		return true
+	})
+
+	return attrs
+}
+
+func TestReceiver_ServePacket(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rh := newRecordHandler()
+	r := NewReceiver(rh, nil)
+	done := make(chan error, 1)
+	go func() { done <- r.ServePacket(pc) }()
+
+	h, err := New(&Options{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Facility: Local3,
+		Tag:      "test",
+	})
+	if err != nil {
+		t.Fatalf("New() = %v; want nil", err)
+	}
+	defer h.Close()
+
+	slog.New(h).WithGroup("g").Warn("a message", "a", 1)
+
+	recs := rh.wait(t, 1)
+	if recs[0].Level != slog.LevelWarn || recs[0].Message != "a message" {
+		t.Errorf("Record = %v %q; want %v %q", recs[0].Level, recs[0].Message, slog.LevelWarn, "a message")
+	}
+	attrs := recordAttrs(recs[0])
+	for k, v := range map[string]string{FacilityKey: "Local3", AppNameKey: "test", "g.a": "1"} {
+		if attrs[k] != v {
+			t.Errorf("Record attribute %q = %q; want %q", k, attrs[k], v)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() = %v; want nil", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("ServePacket() = %v; want nil", err)
+	}
+}
+
+func TestReceiver_Serve(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rh := newRecordHandler()
+	r := NewReceiver(rh, nil)
+	done := make(chan error, 1)
+	go func() { done <- r.Serve(ln) }()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	msgs := "<14>Oct 11 22:14:15 host app: framed\n" +
+		"53 <11>1 2003-10-11T22:14:15.003Z host app - - - counted" +
+		"not syslog at all\n"
+	if _, err := c.Write([]byte(msgs)); err != nil {
+		t.Fatal(err)
+	}
+
+	recs := rh.wait(t, 3)
+	for i, want := range [...]struct {
+		level slog.Level
+		msg   string
+	}{
+		{slog.LevelInfo, "framed"},
+		{slog.LevelError, "counted"},
+		{slog.LevelInfo + 2, "not syslog at all"},
+	} {
+		if recs[i].Level != want.level || recs[i].Message != want.msg {
+			t.Errorf("Record[%d] = %v %q; want %v %q", i, recs[i].Level, recs[i].Message, want.level, want.msg)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() = %v; want nil", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() = %v; want nil", err)
+	}
+}
+
+// temporaryError is a temporary [net.Error].
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "temporary" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+// flakyListener fails to accept the first connections with a temporary error.
+type flakyListener struct {
+	net.Listener
+	failures int
+}
+
+func (l *flakyListener) Accept() (net.Conn, error) {
+	if l.failures > 0 {
+		l.failures--
+		return nil, temporaryError{}
+	}
+
+	return l.Listener.Accept()
+}
+
+func TestReceiver_Serve_Temporary(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rh := newRecordHandler()
+	r := NewReceiver(rh, nil)
+	done := make(chan error, 1)
+	go func() { done <- r.Serve(&flakyListener{Listener: ln, failures: 3}) }()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	if _, err := c.Write([]byte("<14>Oct 11 22:14:15 host app: accepted\n")); err != nil {
+		t.Fatal(err)
+	}
+	if recs := rh.wait(t, 1); recs[0].Message != "accepted" {
+		t.Errorf("Record = %q; want %q", recs[0].Message, "accepted")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Close() = %v; want nil", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Serve() = %v; want nil", err)
+	}
+}
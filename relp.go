@@ -0,0 +1,488 @@
+package slogsyslog
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRELPWindowSize is the default maximum number of messages waiting
+	// for their acknowledgement.
+	DefaultRELPWindowSize = 128
+
+	// DefaultRELPAckTimeout is the default maximum time to wait for an
+	// acknowledgement.
+	DefaultRELPAckTimeout = 10 * time.Second
+
+	// maxRELPTxnr is the largest transaction number, after which they start
+	// over from 1.
+	maxRELPTxnr = 999999999
+
+	// maxRELPDataLen is the maximum size of the data of the responses read.
+	maxRELPDataLen = 64 << 10
+
+	// relpOffers are the offers of the open command.
+	relpOffers = "relp_version=0\nrelp_software=slog-syslog\ncommands=syslog"
+)
+
+var (
+	// errRELPAckTimeout is returned when the acknowledgement of a message
+	// doesn't arrive in time. It is a timeout like those of the connection.
+	errRELPAckTimeout = fmt.Errorf("slogsyslog: RELP acknowledgement timeout: %w", os.ErrDeadlineExceeded)
+
+	// errRELPServerClose is the error of the connections closed by the server.
+	errRELPServerClose = errors.New("slogsyslog: RELP server closed the connection")
+)
+
+// RELPOptions sets the RELP transport used with the "relp" network, which sends
+// messages to an rsyslog imrelp listener over TCP and keeps the ones it hasn't
+// acknowledged yet, resending them after reconnecting. The messages the server
+// refuses are counted as write errors. Messages are only lost when the handler
+// gives up on them, as reported by Handle and Close.
+type RELPOptions struct {
+	// WindowSize is the maximum number of messages waiting for their
+	// acknowledgement. Handle blocks while the window is full. It defaults to
+	// DefaultRELPWindowSize.
+	WindowSize int
+
+	// AckTimeout is the maximum time to wait for an acknowledgement, after
+	// which the connection is considered lost. It defaults to
+	// DefaultRELPAckTimeout.
+	AckTimeout time.Duration
+
+	// AckLevel causes Handle to wait for the acknowledgement of the records
+	// at or above the level, such as those of audit trails. Handle reconnects
+	// once if it doesn't arrive in time and gives up on the record if it
+	// still doesn't. Handle never waits when nil.
+	AckLevel slog.Leveler
+}
+
+// relpFrame is a command waiting for its acknowledgement.
+type relpFrame struct {
+	// txnr is the transaction number of the command in the current session.
+	txnr int
+
+	// command and its data.
+	command string
+	data    []byte
+
+	// done is closed once the command is acknowledged, with err set if the
+	// server refused it, or once the writer gives up on it.
+	done chan struct{}
+	err  error
+}
+
+// relpClient is the RELP session of a writer. The commands are sent by the
+// writer holding its lock while the responses are read by a goroutine.
+type relpClient struct {
+	opts RELPOptions
+
+	// writeTimeout is the writer's write timeout.
+	writeTimeout time.Duration
+
+	// stats count the acknowledged messages.
+	stats *stats
+
+	wg sync.WaitGroup
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// session counts the sessions opened.
+	session uint64
+
+	// txnr is the transaction number of the last command.
+	txnr int
+
+	// frames are the commands waiting for their acknowledgements in the order
+	// they were sent.
+	frames []*relpFrame
+
+	// acked is closed and replaced whenever a command is acknowledged.
+	acked chan struct{}
+
+	// lost is closed once the session's connection is lost, with err set to
+	// why.
+	lost chan struct{}
+	err  error
+}
+
+// newRELPClient returns a RELP client of the options with their defaults.
+func newRELPClient(opts *RELPOptions, writeTimeout time.Duration, st *stats) *relpClient {
+	c := &relpClient{
+		writeTimeout: writeTimeout,
+		stats:        st,
+		acked:        make(chan struct{}),
+		lost:         make(chan struct{}),
+		err:          net.ErrClosed,
+	}
+	if opts != nil {
+		c.opts = *opts
+	}
+	if c.opts.WindowSize <= 0 {
+		c.opts.WindowSize = DefaultRELPWindowSize
+	}
+	if c.opts.AckTimeout <= 0 {
+		c.opts.AckTimeout = DefaultRELPAckTimeout
+	}
+	close(c.lost)
+
+	return c
+}
+
+// waitsAck reports whether Handle waits for the acknowledgement of the records
+// of the level.
+func (c *relpClient) waitsAck(level slog.Level) bool {
+	return c.opts.AckLevel != nil && level >= c.opts.AckLevel.Level()
+}
+
+// open opens a session on the new connection and resends the messages that
+// weren't acknowledged in the previous one.
+func (c *relpClient) open(conn net.Conn) error {
+	conn.SetDeadline(time.Now().Add(c.opts.AckTimeout))
+	if _, err := conn.Write(appendRELPFrame(nil, 1, "open", []byte(relpOffers))); err != nil {
+		return err
+	}
+	r := bufio.NewReader(conn)
+	txnr, command, data, err := readRELPFrame(r)
+	if err != nil {
+		return err
+	}
+	if txnr != 1 || command != "rsp" {
+		return fmt.Errorf("slogsyslog: unexpected RELP command %q", command)
+	}
+	if status, _, _ := bytes.Cut(data, []byte{'\n'}); !bytes.HasPrefix(status, []byte("200")) {
+		return fmt.Errorf("slogsyslog: RELP server refused session: %s", status)
+	}
+	if !slices.Contains(relpCommands(data), "syslog") {
+		return errors.New("slogsyslog: RELP server doesn't support the syslog command")
+	}
+	conn.SetDeadline(time.Time{})
+
+	c.mu.Lock()
+	c.session++
+	c.txnr = 1
+	c.err = nil
+	c.lost = make(chan struct{})
+	var buf []byte
+	for _, f := range c.frames {
+		c.txnr++
+		f.txnr = c.txnr
+		buf = appendRELPFrame(buf, f.txnr, f.command, f.data)
+	}
+	lost := c.lost
+	c.mu.Unlock()
+
+	// The responses are read while the messages are resent, which must not
+	// take longer than their acknowledgements may, so that a server reading
+	// nothing can't block the write.
+	c.wg.Add(1)
+	go c.read(conn, r, lost)
+	if len(buf) == 0 {
+		return nil
+	}
+	conn.SetWriteDeadline(time.Now().Add(c.opts.AckTimeout))
+	if _, err := conn.Write(buf); err != nil {
+		return err
+	}
+	conn.SetWriteDeadline(time.Time{})
+
+	return nil
+}
+
+// send sends a syslog command with b once there is room in the window. It
+// returns the frame waiting for its acknowledgement.
+func (c *relpClient) send(conn net.Conn, b []byte) (*relpFrame, error) {
+	f := &relpFrame{command: "syslog", data: bytes.Clone(b), done: make(chan struct{})}
+
+	timer := time.NewTimer(c.opts.AckTimeout)
+	defer timer.Stop()
+
+	c.mu.Lock()
+	for len(c.frames) >= c.opts.WindowSize && c.err == nil {
+		acked, lost := c.acked, c.lost
+		c.mu.Unlock()
+
+		select {
+		case <-acked:
+		case <-lost:
+		case <-timer.C:
+			return nil, errRELPAckTimeout
+		}
+
+		c.mu.Lock()
+	}
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return nil, err
+	}
+	c.txnr = c.txnr%maxRELPTxnr + 1
+	f.txnr = c.txnr
+	c.frames = append(c.frames, f)
+	c.mu.Unlock()
+
+	if err := c.write(conn, appendRELPFrame(nil, f.txnr, f.command, f.data)); err != nil {
+		// The server discards incomplete frames, so it is sent anew.
+		c.remove(f)
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// wait waits for the acknowledgement of the frame. It reports whether it
+// arrived, along with the server's refusal if any, or else the session in
+// which it didn't and why.
+func (c *relpClient) wait(f *relpFrame) (acked bool, session uint64, err error) {
+	c.mu.Lock()
+	session, lost := c.session, c.lost
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.opts.AckTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-f.done:
+		return true, session, f.err
+	case <-lost:
+		c.mu.Lock()
+		err = c.err
+		c.mu.Unlock()
+		return false, session, err
+	case <-timer.C:
+		return false, session, errRELPAckTimeout
+	}
+}
+
+// unsent returns why the session was lost, if it was with messages waiting for
+// their acknowledgement.
+func (c *relpClient) unsent() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.frames) == 0 {
+		return nil
+	}
+
+	return c.err
+}
+
+// current reports whether the session is the current one.
+func (c *relpClient) current(session uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.session == session
+}
+
+// close sends the close command and waits for its acknowledgement, which the
+// server sends after those of all the previous commands. It then gives up on
+// the messages that weren't acknowledged, returning an error if any.
+func (c *relpClient) close(conn net.Conn) error {
+	var err error
+	if conn != nil {
+		c.mu.Lock()
+		c.txnr = c.txnr%maxRELPTxnr + 1
+		f := &relpFrame{txnr: c.txnr, command: "close", done: make(chan struct{})}
+		c.frames = append(c.frames, f)
+		c.mu.Unlock()
+
+		if err = c.write(conn, appendRELPFrame(nil, f.txnr, f.command, nil)); err == nil {
+			_, _, err = c.wait(f)
+		}
+		c.remove(f)
+		conn.Close()
+	}
+	c.wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n := len(c.frames); n > 0 {
+		for _, f := range c.frames {
+			f.err = net.ErrClosed
+			close(f.done)
+		}
+		c.frames = nil
+		err = errors.Join(err, fmt.Errorf("slogsyslog: %d RELP message(s) not acknowledged", n))
+	}
+
+	return err
+}
+
+// remove gives up on the frame if it is still waiting for its
+// acknowledgement.
+func (c *relpClient) remove(f *relpFrame) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if i := slices.Index(c.frames, f); i >= 0 {
+		c.frames = slices.Delete(c.frames, i, i+1)
+	}
+}
+
+// write writes the frames in b to the connection.
+func (c *relpClient) write(conn net.Conn, b []byte) error {
+	if len(b) == 0 {
+		return nil
+	}
+	if c.writeTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	_, err := conn.Write(b)
+
+	return err
+}
+
+// read reads the responses of the session until its connection is lost.
+func (c *relpClient) read(conn net.Conn, r *bufio.Reader, lost chan struct{}) {
+	defer c.wg.Done()
+	defer close(lost)
+
+	for {
+		txnr, command, data, err := readRELPFrame(r)
+		if err == nil {
+			switch command {
+			case "rsp":
+				c.ack(lost, txnr, data)
+				continue
+			case "serverclose":
+				err = errRELPServerClose
+			default:
+				err = fmt.Errorf("slogsyslog: unexpected RELP command %q", command)
+			}
+		}
+
+		conn.Close()
+		c.mu.Lock()
+		if c.lost == lost {
+			c.err = err
+		}
+		c.mu.Unlock()
+		return
+	}
+}
+
+// ack acknowledges the command of the session with the transaction number.
+// Messages whose response isn't a success are refused.
+func (c *relpClient) ack(lost chan struct{}, txnr int, data []byte) {
+	c.mu.Lock()
+	i := slices.IndexFunc(c.frames, func(f *relpFrame) bool { return f.txnr == txnr })
+	if c.lost != lost || i < 0 {
+		c.mu.Unlock()
+		return
+	}
+	f := c.frames[i]
+	c.frames = slices.Delete(c.frames, i, i+1)
+	close(c.acked)
+	c.acked = make(chan struct{})
+	c.mu.Unlock()
+
+	status, _, _ := bytes.Cut(data, []byte{'\n'})
+	if f.command == "syslog" {
+		if bytes.HasPrefix(status, []byte("200")) {
+			c.stats.written.Add(1)
+			c.stats.bytes.Add(uint64(len(f.data)))
+		} else {
+			f.err = fmt.Errorf("slogsyslog: RELP server refused message: %s", status)
+			c.stats.writeError(f.err)
+		}
+	}
+	close(f.done)
+}
+
+// relpCommands returns the commands offered in the data of the response to the
+// open command.
+func relpCommands(data []byte) []string {
+	for _, line := range strings.Split(string(data), "\n") {
+		if commands, ok := strings.CutPrefix(line, "commands="); ok {
+			return strings.Split(commands, ",")
+		}
+	}
+
+	return nil
+}
+
+// appendRELPFrame appends the frame of the command to b.
+func appendRELPFrame(b []byte, txnr int, command string, data []byte) []byte {
+	b = strconv.AppendInt(b, int64(txnr), 10)
+	b = append(b, ' ')
+	b = append(b, command...)
+	b = append(b, ' ')
+	b = strconv.AppendInt(b, int64(len(data)), 10)
+	if len(data) > 0 {
+		b = append(b, ' ')
+		b = append(b, data...)
+	}
+
+	return append(b, '\n')
+}
+
+// readRELPFrame reads a frame.
+func readRELPFrame(r *bufio.Reader) (txnr int, command string, data []byte, err error) {
+	field, err := r.ReadString(' ')
+	if err != nil {
+		return 0, "", nil, err
+	}
+	txnr, err = strconv.Atoi(field[:len(field)-1])
+	if err != nil || txnr < 0 || txnr > maxRELPTxnr {
+		return 0, "", nil, ErrMalformed
+	}
+
+	if command, err = r.ReadString(' '); err != nil {
+		return 0, "", nil, noEOF(err)
+	}
+	command = command[:len(command)-1]
+
+	var n int
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return 0, "", nil, noEOF(err)
+		}
+		if c == '\n' && n == 0 {
+			return txnr, command, nil, nil
+		}
+		if c == ' ' {
+			break
+		}
+		if c < '0' || c > '9' || n > maxRELPDataLen {
+			return 0, "", nil, ErrMalformed
+		}
+		n = n*10 + int(c-'0')
+	}
+	if n > maxRELPDataLen {
+		return 0, "", nil, ErrMalformed
+	}
+
+	data = make([]byte, n+1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, "", nil, noEOF(err)
+	}
+	if data[n] != '\n' {
+		return 0, "", nil, ErrMalformed
+	}
+
+	return txnr, command, data[:n], nil
+}
+
+// noEOF turns the end of the stream within a frame into an unexpected one.
+func noEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
@@ -0,0 +1,78 @@
+package slogsyslog
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestAppendRELPFrame(t *testing.T) {
+	testCases := [...]struct {
+		name    string
+		txnr    int
+		command string
+		data    string
+		want    string
+	}{
+		{name: "Data", txnr: 2, command: "syslog", data: "<13>msg\nnext", want: "2 syslog 12 <13>msg\nnext\n"},
+		{name: "NoData", txnr: 999999999, command: "close", want: "999999999 close 0\n"},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := string(appendRELPFrame(nil, tc.txnr, tc.command, []byte(tc.data)))
+			if got != tc.want {
+				t.Errorf("appendRELPFrame(%d, %q, %q) = %q; want %q", tc.txnr, tc.command, tc.data, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestReadRELPFrame(t *testing.T) {
+	testCases := [...]struct {
+		name    string
+		frame   string
+		txnr    int
+		command string
+		data    string
+		wantErr bool
+	}{
+		{name: "Response", frame: "3 rsp 6 200 OK\n", txnr: 3, command: "rsp", data: "200 OK"},
+		{name: "NoData", frame: "0 serverclose 0\n", command: "serverclose"},
+		{name: "NewLines", frame: "1 rsp 10 200 OK\na=b\n", txnr: 1, command: "rsp", data: "200 OK\na=b"},
+		{name: "MissingTrailer", frame: "3 rsp 6 200 OKX", wantErr: true},
+		{name: "Truncated", frame: "3 rsp 6 200", wantErr: true},
+		{name: "InvalidTxnr", frame: "x rsp 0\n", wantErr: true},
+		{name: "InvalidLength", frame: "3 rsp 6x 200 OK\n", wantErr: true},
+		{name: "MissingData", frame: "3 rsp 6\n", wantErr: true},
+		{name: "TooLarge", frame: "3 rsp 99999999 200 OK\n", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			txnr, command, data, err := readRELPFrame(bufio.NewReader(strings.NewReader(tc.frame)))
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("readRELPFrame(%q) = %d %q %q; want error", tc.frame, txnr, command, data)
+				}
+				return
+			}
+			if err != nil || txnr != tc.txnr || command != tc.command || string(data) != tc.data {
+				t.Errorf("readRELPFrame(%q) = %d %q %q, %v; want %d %q %q", tc.frame, txnr, command, data, err, tc.txnr, tc.command, tc.data)
+			}
+		})
+	}
+}
+
+func TestRELPCommands(t *testing.T) {
+	got := relpCommands([]byte("200 OK\nrelp_version=0\ncommands=syslog,eventlog"))
+	if want := []string{"syslog", "eventlog"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("relpCommands() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,136 @@
+package slogsyslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// repeater suppresses identical consecutive records passed to the handlers
+// sharing it, writing how many times the last one was repeated instead.
+type repeater struct {
+	// window is how long identical records are suppressed for.
+	window time.Duration
+
+	mu sync.Mutex
+
+	// key identifies the last record written and start is when it was.
+	key   string
+	start time.Time
+
+	// count is the number of records suppressed since, last is the last one
+	// of them and h is the handler it was passed to.
+	count int
+	last  slog.Record
+	h     *SyslogHandler
+
+	// timer writes the summary when the window expires. gen is changed
+	// whenever it is stopped, so that a timer firing while being stopped
+	// doesn't write the summary of the records suppressed afterwards.
+	timer *time.Timer
+	gen   uint64
+}
+
+// newRepeater returns a repeater suppressing records within the window.
+func newRepeater(window time.Duration) *repeater {
+	return &repeater{window: window}
+}
+
+// handle writes the record unless it is identical to the last one written
+// within the window.
+func (rp *repeater) handle(ctx context.Context, h *SyslogHandler, r slog.Record) error {
+	key := repeatKey(r, h.formatOptions())
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	now := time.Now()
+	if key == rp.key && now.Sub(rp.start) < rp.window {
+		rp.count++
+		rp.last, rp.h = r.Clone(), h
+		h.drop(DropRepeated, r, nil)
+		if rp.timer == nil {
+			gen := rp.gen
+			rp.timer = time.AfterFunc(rp.window-now.Sub(rp.start), func() { rp.expire(gen) })
+		}
+		return nil
+	}
+
+	// The summary of the previous records goes first, so that it isn't
+	// mistaken for a summary of this one.
+	err := rp.flush(ctx)
+	rp.key, rp.start = key, now
+
+	return errors.Join(err, h.handle(ctx, r))
+}
+
+// expire writes the summary once the window of the timer started in the
+// generation expires, unless the timer was stopped since. The next record is
+// written even if it is identical.
+func (rp *repeater) expire(gen uint64) {
+	rp.mu.Lock()
+	if gen != rp.gen {
+		rp.mu.Unlock()
+		return
+	}
+	h := rp.h
+	rp.timer = nil
+	rp.flush(context.Background())
+	rp.key = ""
+	rp.mu.Unlock()
+
+	if h != nil {
+		h.hooks.run()
+	}
+}
+
+// close writes the summary of the records suppressed so far.
+func (rp *repeater) close() error {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+
+	err := rp.flush(context.Background())
+	rp.key = ""
+
+	return err
+}
+
+// flush writes the summary of the suppressed records, if any, as a record with
+// the level and time of the last one.
+func (rp *repeater) flush(ctx context.Context) error {
+	if rp.timer != nil {
+		rp.timer.Stop()
+		rp.timer = nil
+		rp.gen++
+	}
+	if rp.count == 0 {
+		return nil
+	}
+
+	msg := "last message repeated " + strconv.Itoa(rp.count) + " times"
+	r := slog.NewRecord(rp.last.Time, rp.last.Level, msg, 0)
+	h := rp.h
+	rp.count, rp.last, rp.h = 0, slog.Record{}, nil
+
+	return h.handle(ctx, r)
+}
+
+// repeatKey returns what identifies identical records: their level, message
+// and rendered attributes, including the handler's.
+func repeatKey(r slog.Record, opts formatOptions) string {
+	buf := make([]byte, 0, 128)
+	buf = append(buf, r.Level.String()...)
+	buf = append(buf, 0)
+	buf = append(buf, r.Message...)
+	flattenAttrs(r, opts, false, func(key string, v slog.Value) {
+		buf = append(buf, 0)
+		buf = append(buf, key...)
+		buf = append(buf, '=')
+		buf = append(buf, extValue(attrTime(v, opts))...)
+	})
+
+	return string(buf)
+}
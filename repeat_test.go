@@ -0,0 +1,156 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRepeatHandler returns a handler suppressing repeated records within the
+// window and the connection receiving its messages.
+func newRepeatHandler(t *testing.T, window time.Duration) (*SyslogHandler, net.PacketConn) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+
+	h, err := New(&Options{Network: "udp", Address: pc.LocalAddr().String(), Format: FormatRFC3164, RepeatWindow: window})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { h.Close() })
+
+	return h, pc
+}
+
+// readMessages reads n messages and returns their text after the header.
+func readMessages(t *testing.T, pc net.PacketConn, n int) []string {
+	t.Helper()
+
+	msgs := make([]string, 0, n)
+	b := make([]byte, 64<<10)
+	for i := 0; i < n; i++ {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		m, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, msg, _ := strings.Cut(string(b[:m]), "]: ")
+		msgs = append(msgs, strings.TrimSuffix(msg, "\n"))
+	}
+
+	return msgs
+}
+
+// expectNoMessage fails if a message is received shortly.
+func expectNoMessage(t *testing.T, pc net.PacketConn) {
+	t.Helper()
+
+	pc.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if n, _, err := pc.ReadFrom(make([]byte, 64<<10)); err == nil {
+		t.Errorf("ReadFrom() = %d bytes; want timeout", n)
+	}
+}
+
+func TestSyslogHandler_RepeatWindow(t *testing.T) {
+	h, pc := newRepeatHandler(t, time.Hour)
+	ctx := context.Background()
+
+	hx := h.WithAttrs([]slog.Attr{slog.Int("x", 1)})
+	for _, rec := range [...]struct {
+		h   slog.Handler
+		msg string
+	}{
+		{h, "a"}, {h, "a"}, {h, "a"}, {hx, "a"}, {hx, "a"}, {h, "b"},
+	} {
+		if err := rec.h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, rec.msg, 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+
+	// The summary is written by the handler of the repeated records, along
+	// with its attributes.
+	want := []string{"a", "last message repeated 2 times", "[x=\"1\"] a", "[x=\"1\"] last message repeated 1 times", "b"}
+	got := readMessages(t, pc, len(want))
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Messages = %q; want %q", got, want)
+	}
+	expectNoMessage(t, pc)
+
+	if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, "b", 0)); err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+	if got := readMessages(t, pc, 1); got[0] != "last message repeated 1 times" {
+		t.Errorf("Message after Close() = %q; want %q", got[0], "last message repeated 1 times")
+	}
+}
+
+func TestSyslogHandler_RepeatWindow_Expiry(t *testing.T) {
+	h, pc := newRepeatHandler(t, 50*time.Millisecond)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, "a", 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+
+	want := []string{"a", "last message repeated 2 times"}
+	if got := readMessages(t, pc, len(want)); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Messages = %q; want %q", got, want)
+	}
+
+	// The window is over, so the record is written in full again.
+	if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, "a", 0)); err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+	if got := readMessages(t, pc, 1); got[0] != "a" {
+		t.Errorf("Message = %q; want %q", got[0], "a")
+	}
+}
+
+func TestSyslogHandler_RepeatWindow_StaleTimer(t *testing.T) {
+	h, pc := newRepeatHandler(t, time.Hour)
+	ctx := context.Background()
+
+	handle := func(msg string) {
+		t.Helper()
+		if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelError, msg, 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+
+	handle("a")
+	handle("a")
+	h.repeats.mu.Lock()
+	gen := h.repeats.gen
+	h.repeats.mu.Unlock()
+	handle("b")
+	handle("b")
+
+	// The timer of the first records fires after it failed to stop.
+	h.repeats.expire(gen)
+
+	want := []string{"a", "last message repeated 1 times", "b"}
+	if got := readMessages(t, pc, len(want)); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Messages = %q; want %q", got, want)
+	}
+	expectNoMessage(t, pc)
+
+	handle("b")
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+	if got := readMessages(t, pc, 1); got[0] != "last message repeated 2 times" {
+		t.Errorf("Message after Close() = %q; want %q", got[0], "last message repeated 2 times")
+	}
+}
@@ -0,0 +1,143 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strconv"
+)
+
+const (
+	// maxLogValues is the maximum number of LogValue calls resolving a
+	// value, the same as allowed by [log/slog.Value.Resolve].
+	maxLogValues = 100
+
+	// maxGroupDepth is the maximum depth of nested groups, which stops
+	// LogValue methods returning groups holding the value itself.
+	maxGroupDepth = 32
+)
+
+// panicValue returns the string written instead of a value whose method
+// panicked.
+func panicValue(r, val any) string {
+	// Like the standard library's text handler, a method of a nil pointer
+	// failing to guard against nil is taken for a nil value.
+	if v := reflect.ValueOf(val); v.Kind() == reflect.Pointer && v.IsNil() {
+		return "<nil>"
+	}
+
+	return fmt.Sprintf("!PANIC: %v", r)
+}
+
+// resolveValue is [log/slog.Value.Resolve] writing LogValue panics as !PANIC:
+// values.
+func resolveValue(v slog.Value) slog.Value {
+	for i := 0; v.Kind() == slog.KindLogValuer; i++ {
+		if i == maxLogValues {
+			return slog.StringValue(fmt.Sprintf("!ERROR: LogValue called too many times on type %T", v.Any()))
+		}
+		v = logValue(v.LogValuer())
+	}
+
+	return v
+}
+
+// logValue calls the LogValue method recovering from panics.
+func logValue(lv slog.LogValuer) (v slog.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			v = slog.StringValue(panicValue(r, lv))
+		}
+	}()
+
+	return lv.LogValue()
+}
+
+// limitDepth replaces a group nested too deeply by an error.
+func limitDepth(v slog.Value, depth int) slog.Value {
+	if v.Kind() == slog.KindGroup && depth >= maxGroupDepth {
+		return slog.StringValue("!ERROR: group nested too deeply")
+	}
+
+	return v
+}
+
+// encodeValue calls the value encoder recovering from panics.
+func encodeValue(enc ValueEncoder, a slog.Attr) (attrs []slog.Attr) {
+	defer func() {
+		if r := recover(); r != nil {
+			attrs = []slog.Attr{slog.String(a.Key, panicValue(r, a.Value.Any()))}
+		}
+	}()
+
+	return enc(a)
+}
+
+// anyString returns the string written for a value of kind Any. It is the
+// value's text if it is a [encoding.TextMarshaler], the bytes of a byte slice
+// or the value formatted with %+v. Panics of the value's methods are written
+// as !PANIC: values.
+func anyString(val any) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = panicValue(r, val)
+		}
+	}()
+
+	switch v := val.(type) {
+	case *slog.Source:
+		return v.File + ":" + strconv.Itoa(v.Line)
+	case encoding.TextMarshaler:
+		data, err := v.MarshalText()
+		if err != nil {
+			return "!ERROR:" + err.Error()
+		}
+		return string(data)
+	}
+
+	if bs, ok := byteSlice(val); ok {
+		return string(bs)
+	}
+
+	switch v := val.(type) {
+	case fmt.Formatter:
+		// fmt would recover from panics itself, so the method is called
+		// directly.
+		var st formatState
+		v.Format(&st, 'v')
+		return st.String()
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	return fmt.Sprintf("%+v", val)
+}
+
+// byteSlice returns the bytes of a value whose type is a byte slice.
+func byteSlice(val any) ([]byte, bool) {
+	if bs, ok := val.([]byte); ok {
+		return bs, true
+	}
+
+	t := reflect.TypeOf(val)
+	if t != nil && t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return reflect.ValueOf(val).Bytes(), true
+	}
+
+	return nil, false
+}
+
+// formatState is the [fmt.State] of the %+v verb.
+type formatState struct {
+	bytes.Buffer
+}
+
+func (*formatState) Width() (int, bool) { return 0, false }
+
+func (*formatState) Precision() (int, bool) { return 0, false }
+
+func (*formatState) Flag(c int) bool { return c == '+' }
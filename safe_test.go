@@ -0,0 +1,204 @@
+package slogsyslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+type panicText struct{}
+
+func (panicText) MarshalText() ([]byte, error) { panic("text") }
+
+type panicString struct{}
+
+func (panicString) String() string { panic("string") }
+
+type panicFormat struct{}
+
+func (panicFormat) Format(fmt.State, rune) { panic("format") }
+
+type panicLogValue struct{}
+
+func (panicLogValue) LogValue() slog.Value { panic("log value") }
+
+type nilText struct{ s string }
+
+func (t *nilText) MarshalText() ([]byte, error) { return []byte(t.s), nil }
+
+// loopValue resolves to itself forever.
+type loopValue struct{}
+
+func (v loopValue) LogValue() slog.Value { return slog.AnyValue(v) }
+
+// nestValue resolves to a group holding itself.
+type nestValue struct{}
+
+func (v nestValue) LogValue() slog.Value { return slog.GroupValue(slog.Any("n", v)) }
+
+func TestAnyString(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		val  any
+		want string
+	}{
+		{
+			name: "Source",
+			val:  &slog.Source{File: "main.go", Line: 3},
+			want: "main.go:3",
+		},
+		{
+			name: "TextError",
+			val:  errorText{},
+			want: "!ERROR:cannot marshal",
+		},
+		{
+			name: "Bytes",
+			val:  []byte("abc"),
+			want: "abc",
+		},
+		{
+			name: "Error",
+			val:  errors.New("failed"),
+			want: "failed",
+		},
+		{
+			name: "Formatter",
+			val:  verboseError{},
+			want: "verbose\nat main.go:1",
+		},
+		{
+			name: "Struct",
+			val:  struct{ A int }{1},
+			want: "{A:1}",
+		},
+		{
+			name: "PanicText",
+			val:  panicText{},
+			want: "!PANIC: text",
+		},
+		{
+			name: "PanicString",
+			val:  panicString{},
+			want: "!PANIC: string",
+		},
+		{
+			name: "PanicFormat",
+			val:  panicFormat{},
+			want: "!PANIC: format",
+		},
+		{
+			name: "NilPointer",
+			val:  (*nilText)(nil),
+			want: "<nil>",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := anyString(tc.val); got != tc.want {
+				t.Errorf("anyString(%T) = %q; want %q", tc.val, got, tc.want)
+			}
+		})
+	}
+}
+
+// errorText fails to marshal itself.
+type errorText struct{}
+
+func (errorText) MarshalText() ([]byte, error) { return nil, errors.New("cannot marshal") }
+
+func TestResolveValue(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		v    slog.Value
+		want string
+	}{
+		{
+			name: "Panic",
+			v:    slog.AnyValue(panicLogValue{}),
+			want: "!PANIC: log value",
+		},
+		{
+			name: "Loop",
+			v:    slog.AnyValue(loopValue{}),
+			want: "!ERROR: LogValue called too many times on type slogsyslog.loopValue",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := resolveValue(tc.v).String(); got != tc.want {
+				t.Errorf("resolveValue(%v) = %q; want %q", tc.v, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Panics(t *testing.T) {
+	attrs := []slog.Attr{
+		slog.Any("text", panicText{}),
+		slog.Any("lv", panicLogValue{}),
+		slog.Any("nest", nestValue{}),
+	}
+	deep := "nest" + strings.Repeat(".n", maxGroupDepth)
+	// The styled attributes prefix nested keys in reverse order.
+	deepStyled := strings.Repeat("n.", maxGroupDepth-1) + "nest.n"
+	testCases := [...]struct {
+		name   string
+		format messageFormatter
+		opts   formatOptions
+		want   []string
+	}{
+		{
+			name:   "Logfmt",
+			format: goFormat,
+			opts:   formatOptions{AttrStyle: AttrStyleLogfmt},
+			want:   []string{`text="!PANIC: text"`, `lv="!PANIC: log value"`, deepStyled + `="!ERROR: group nested too deeply"`},
+		},
+		{
+			name:   "CEE",
+			format: ceeFormat,
+			want:   []string{`"text":"!PANIC: text"`, `"lv":"!PANIC: log value"`, `"n":"!ERROR: group nested too deeply"`},
+		},
+		{
+			name:   "GELF",
+			format: gelfFormat,
+			want:   []string{`"_text":"!PANIC: text"`, `"_lv":"!PANIC: log value"`, `"_` + deep + `":"!ERROR: group nested too deeply"`},
+		},
+		{
+			name:   "Encoder",
+			format: goFormat,
+			opts: formatOptions{AttrStyle: AttrStyleLogfmt, ValueEncoder: func(a slog.Attr) []slog.Attr {
+				panic("encoder")
+			}},
+			want: []string{`text="!PANIC: encoder"`},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := slog.NewRecord(testTime, slog.LevelInfo, "a message", 0)
+			r.AddAttrs(attrs...)
+
+			got := string(tc.format(context.Background(), nil, r, tc.opts))
+			for _, want := range tc.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("format(%v) = %q; want it to contain %q", r, got, want)
+				}
+			}
+		})
+	}
+}
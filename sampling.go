@@ -0,0 +1,350 @@
+package slogsyslog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSamplingReport is the default interval of the record reporting
+	// the number of records dropped by sampling.
+	DefaultSamplingReport = time.Minute
+
+	// maxSampleKeys is the maximum number of messages or attribute values
+	// whose budgets are tracked. They are all forgotten when there are more.
+	maxSampleKeys = 4096
+)
+
+// SamplingRule limits the records of a level written. A record is written only
+// if it passes every limit that is set.
+type SamplingRule struct {
+	// Rate is the number of records per second allowed by a token bucket.
+	// There is no limit if zero.
+	Rate float64
+
+	// Burst is the size of the token bucket, that is the number of records
+	// written at once before they are limited to the rate. It defaults to the
+	// rate rounded up.
+	Burst int
+
+	// First is the number of records written during each period before only
+	// every Thereafter-th record is. There is no limit if zero.
+	First int
+
+	// Thereafter is how often records are written during the period once
+	// the first ones are. None are if zero.
+	Thereafter int
+
+	// Period is the period of First and Thereafter. It defaults to a second.
+	Period time.Duration
+
+	// Probability is the probability of a record being written. There is no
+	// limit if zero.
+	Probability float64
+
+	// By determines which records share the limits.
+	By SampleBy
+
+	// Attr is the key of the attribute whose value shares the limits when
+	// sampling by attribute.
+	Attr string
+}
+
+// validate checks the rule's settings.
+func (r SamplingRule) validate() error {
+	switch {
+	case r.Rate < 0:
+		return errors.New("negative rate")
+	case r.Burst < 0:
+		return errors.New("negative burst")
+	case r.First < 0 || r.Thereafter < 0:
+		return errors.New("negative first or thereafter")
+	case r.Probability < 0 || r.Probability > 1:
+		return errors.New("probability out of range")
+	case r.By == SampleByAttr && r.Attr == "":
+		return errors.New("missing attribute key")
+	default:
+		return nil
+	}
+}
+
+// levelRule is a sampling rule along with the lowest level it applies to.
+type levelRule struct {
+	level slog.Level
+	SamplingRule
+}
+
+// sampleState is the budget of the records sharing the limits of a rule.
+type sampleState struct {
+	// tokens is the number of tokens in the bucket when last refilled.
+	tokens float64
+	filled time.Time
+
+	// count is the number of records since the period started.
+	count  int
+	period time.Time
+}
+
+// sampler drops the records exceeding the sampling rules for all the handlers
+// sharing it and reports how many were dropped.
+type sampler struct {
+	// rules are sorted by their level from the highest.
+	rules []levelRule
+
+	mu      sync.Mutex
+	states  map[string]*sampleState
+	dropped map[slog.Level]uint64
+
+	// rnd draws the records written with a probability. It is protected by
+	// mu, so that the samplers don't contend on the global source.
+	rnd *rand.Rand
+
+	// h writes the reports until done is closed.
+	h    *SyslogHandler
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+// newSampler returns a sampler applying the rules of the levels.
+func newSampler(rules map[slog.Level]SamplingRule) (*sampler, error) {
+	s := &sampler{
+		states:  make(map[string]*sampleState),
+		dropped: make(map[slog.Level]uint64),
+		rnd:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		done:    make(chan struct{}),
+	}
+	for level, r := range rules {
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("slogsyslog: invalid sampling rule for level %s: %w", level, err)
+		}
+
+		if r.Burst == 0 {
+			r.Burst = max(1, int(r.Rate+0.999))
+		}
+		if r.Period <= 0 {
+			r.Period = time.Second
+		}
+		s.rules = append(s.rules, levelRule{level, r})
+	}
+	slices.SortFunc(s.rules, func(a, b levelRule) int {
+		return int(b.level - a.level)
+	})
+
+	return s, nil
+}
+
+// rule returns the index of the rule of the level or -1 if there is none.
+func (s *sampler) rule(level slog.Level) int {
+	for i, r := range s.rules {
+		if level >= r.level {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// exhausted reports whether the records of the level will be dropped whatever
+// they are. It doesn't consume the budget or count anything.
+func (s *sampler) exhausted(level slog.Level) bool {
+	i := s.rule(level)
+	if i < 0 || s.rules[i].By != SampleByLevel {
+		return false
+	}
+	r := &s.rules[i]
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.states[strconv.Itoa(i)]
+	if st == nil {
+		return false
+	}
+
+	now := time.Now()
+	bucket := r.Rate > 0 && st.refill(r, now) < 1
+	period := r.First > 0 && r.Thereafter == 0 && now.Sub(st.period) < r.Period && st.count >= r.First
+
+	return bucket || period
+}
+
+// allow reports whether the record is written, consuming its budget. Dropped
+// records are counted.
+func (s *sampler) allow(r slog.Record, goas []groupOrAttrs) bool {
+	i := s.rule(r.Level)
+	if i < 0 {
+		return true
+	}
+	rule := &s.rules[i]
+
+	key := strconv.Itoa(i)
+	switch rule.By {
+	case SampleByMessage:
+		key += "\x00" + r.Message
+	case SampleByAttr:
+		key += "\x00" + sampleAttr(r, goas, rule.Attr)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.states[key]
+	if st == nil {
+		if len(s.states) >= maxSampleKeys {
+			clear(s.states)
+		}
+		st = &sampleState{tokens: float64(rule.Burst)}
+		s.states[key] = st
+	}
+
+	if !st.allow(rule, time.Now(), s.rnd) {
+		s.dropped[r.Level]++
+		return false
+	}
+
+	return true
+}
+
+// allow consumes the budget of a record, reporting whether there was any. The
+// probability is drawn from rnd.
+func (st *sampleState) allow(r *levelRule, now time.Time, rnd *rand.Rand) bool {
+	if r.First > 0 {
+		if now.Sub(st.period) >= r.Period {
+			st.period, st.count = now, 0
+		}
+		st.count++
+		if st.count > r.First && (r.Thereafter == 0 || (st.count-r.First)%r.Thereafter != 0) {
+			return false
+		}
+	}
+
+	if r.Rate > 0 {
+		if st.refill(r, now) < 1 {
+			return false
+		}
+		st.tokens--
+	}
+
+	return r.Probability == 0 || rnd.Float64() < r.Probability
+}
+
+// refill adds the tokens accumulated since the last refill and returns how many
+// there are.
+func (st *sampleState) refill(r *levelRule, now time.Time) float64 {
+	if !st.filled.IsZero() {
+		st.tokens = min(float64(r.Burst), st.tokens+now.Sub(st.filled).Seconds()*r.Rate)
+	}
+	st.filled = now
+
+	return st.tokens
+}
+
+// sampleAttr returns the value of the record's or handler's attribute with the
+// key, ignoring those in groups.
+func sampleAttr(r slog.Record, goas []groupOrAttrs, key string) string {
+	var v string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			v = resolveValue(a.Value).String()
+			return false
+		}
+		return true
+	})
+	if v != "" {
+		return v
+	}
+
+	for _, goa := range goas {
+		if goa.group != "" {
+			break
+		}
+		for _, a := range goa.attrs {
+			if a.Key == key {
+				v = resolveValue(a.Value).String()
+			}
+		}
+	}
+
+	return v
+}
+
+// report returns the record reporting the number of records dropped since the
+// last one, if any.
+func (s *sampler) report() (slog.Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.dropped) == 0 {
+		return slog.Record{}, false
+	}
+
+	levels := make([]slog.Level, 0, len(s.dropped))
+	for level := range s.dropped {
+		levels = append(levels, level)
+	}
+	slices.Sort(levels)
+
+	var (
+		total uint64
+		attrs = make([]any, 0, len(levels))
+	)
+	for _, level := range levels {
+		total += s.dropped[level]
+		attrs = append(attrs, slog.Uint64(level.String(), s.dropped[level]))
+	}
+	clear(s.dropped)
+
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "sampling dropped "+strconv.FormatUint(total, 10)+" records", 0)
+	r.AddAttrs(slog.Group("dropped", attrs...))
+
+	return r, true
+}
+
+// start writes the report with the handler every interval until closed.
+func (s *sampler) start(h *SyslogHandler, interval time.Duration) {
+	s.h = h
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if r, ok := s.report(); ok && h.levelEnabled(r.Level) {
+					h.handle(context.Background(), r)
+					h.hooks.run()
+				}
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// close stops reporting and writes the last report, if reporting at all.
+func (s *sampler) close() error {
+	var err error
+	s.stop.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+
+		if s.h == nil {
+			return
+		}
+		if r, ok := s.report(); ok && s.h.levelEnabled(r.Level) {
+			err = s.h.handle(context.Background(), r)
+		}
+	})
+
+	return err
+}
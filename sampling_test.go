@@ -0,0 +1,219 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampleState_Allow(t *testing.T) {
+	start := time.Unix(0, 0)
+	testCases := [...]struct {
+		name  string
+		rule  SamplingRule
+		times []time.Duration
+		want  []bool
+	}{
+		{
+			name:  "TokenBucket",
+			rule:  SamplingRule{Rate: 2, Burst: 2},
+			times: []time.Duration{0, 0, 0, 250 * time.Millisecond, 500 * time.Millisecond, 500 * time.Millisecond, 5 * time.Second, 5 * time.Second, 5 * time.Second},
+			want:  []bool{true, true, false, false, true, false, true, true, false},
+		},
+		{
+			name:  "FirstThereafter",
+			rule:  SamplingRule{First: 2, Thereafter: 3},
+			times: []time.Duration{0, 0, 0, 0, 0, 0, 0, time.Second, time.Second},
+			want:  []bool{true, true, false, false, true, false, false, true, true},
+		},
+		{
+			name:  "FirstOnly",
+			rule:  SamplingRule{First: 1, Period: time.Minute},
+			times: []time.Duration{0, time.Second, time.Minute},
+			want:  []bool{true, false, true},
+		},
+		{
+			name:  "Probability",
+			rule:  SamplingRule{Probability: 1},
+			times: []time.Duration{0, 0},
+			want:  []bool{true, true},
+		},
+		{
+			name:  "Unlimited",
+			times: []time.Duration{0, 0},
+			want:  []bool{true, true},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := newSampler(map[slog.Level]SamplingRule{slog.LevelInfo: tc.rule})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := &s.rules[0]
+			st := &sampleState{tokens: float64(r.Burst)}
+			for i, d := range tc.times {
+				if got := st.allow(r, start.Add(d), s.rnd); got != tc.want[i] {
+					t.Errorf("allow() #%d at %v = %t; want %t", i, d, got, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSampler_Allow(t *testing.T) {
+	s, err := newSampler(map[slog.Level]SamplingRule{
+		slog.LevelDebug: {First: 1, By: SampleByMessage},
+		slog.LevelInfo:  {First: 1, By: SampleByAttr, Attr: "user"},
+		slog.LevelError: {},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	goas := []groupOrAttrs{{attrs: []slog.Attr{slog.String("user", "bob")}}}
+	for i, tc := range [...]struct {
+		level slog.Level
+		msg   string
+		attrs []slog.Attr
+		want  bool
+	}{
+		{slog.LevelDebug, "a", nil, true},
+		{slog.LevelDebug, "a", nil, false},
+		{slog.LevelDebug, "b", nil, true},
+		{slog.LevelInfo, "a", []slog.Attr{slog.String("user", "alice")}, true},
+		{slog.LevelWarn, "b", []slog.Attr{slog.String("user", "alice")}, false},
+		{slog.LevelInfo, "c", nil, true},
+		{slog.LevelInfo, "c", nil, false},
+		{slog.LevelError, "d", nil, true},
+		{slog.LevelError, "d", nil, true},
+		{slog.LevelDebug - 4, "e", nil, true},
+		{slog.LevelDebug - 4, "e", nil, true},
+	} {
+		r := slog.NewRecord(testTime, tc.level, tc.msg, 0)
+		r.AddAttrs(tc.attrs...)
+		if got := s.allow(r, goas); got != tc.want {
+			t.Errorf("allow(%v) #%d = %t; want %t", r, i, got, tc.want)
+		}
+	}
+
+	r, ok := s.report()
+	if !ok {
+		t.Fatal("report() = false; want true")
+	}
+	if want := "sampling dropped 3 records"; r.Message != want {
+		t.Errorf("report() message = %q; want %q", r.Message, want)
+	}
+	var got string
+	r.Attrs(func(a slog.Attr) bool {
+		got = a.String()
+		return true
+	})
+	if want := "dropped=[DEBUG=1 INFO=1 WARN=1]"; got != want {
+		t.Errorf("report() attrs = %q; want %q", got, want)
+	}
+	if _, ok := s.report(); ok {
+		t.Error("report() after report() = true; want false")
+	}
+}
+
+func TestNewSampler_Invalid(t *testing.T) {
+	for _, rule := range [...]SamplingRule{
+		{Rate: -1},
+		{Probability: 2},
+		{By: SampleByAttr},
+	} {
+		if _, err := newSampler(map[slog.Level]SamplingRule{slog.LevelInfo: rule}); err == nil {
+			t.Errorf("newSampler(%+v) = nil; want error", rule)
+		}
+	}
+}
+
+func TestSyslogHandler_Sampling(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := New(&Options{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Format:   FormatRFC3164,
+		Level:    slog.LevelDebug,
+		Sampling: map[slog.Level]SamplingRule{slog.LevelInfo: {First: 1, Period: time.Hour}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "a", 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+	// Probing the level doesn't count as dropping a record.
+	for i := 0; i < 2; i++ {
+		if h.Enabled(ctx, slog.LevelInfo) {
+			t.Errorf("Enabled(%v) = true; want false", slog.LevelInfo)
+		}
+	}
+	if !h.Enabled(ctx, slog.LevelDebug) {
+		t.Errorf("Enabled(%v) = false; want true", slog.LevelDebug)
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+
+	if n := h.Stats().Dropped[DropSampled]; n != 2 {
+		t.Errorf("Dropped = %d; want 2", n)
+	}
+	want := []string{"a", `[dropped.INFO="2"] sampling dropped 2 records`}
+	if got := readMessages(t, pc, len(want)); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Messages = %q; want %q", got, want)
+	}
+}
+
+func TestSyslogHandler_SamplingReport_Level(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := New(&Options{
+		Network:  "udp",
+		Address:  pc.LocalAddr().String(),
+		Format:   FormatRFC3164,
+		Level:    slog.LevelError,
+		Sampling: map[slog.Level]SamplingRule{slog.LevelError: {First: 1, Period: time.Hour}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelError, "a", 0)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+
+	// The report is a warning, which is below the handler's level.
+	if got := readMessages(t, pc, 1); got[0] != "a" {
+		t.Errorf("Message = %q; want %q", got[0], "a")
+	}
+	expectNoMessage(t, pc)
+}
@@ -0,0 +1,210 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ErrInvalid is returned in the strict mode for records that would otherwise
+// have to be repaired to produce a valid message.
+var ErrInvalid = errors.New("slogsyslog: invalid message")
+
+// bom is the UTF-8 byte order mark preceding RFC 5424 messages.
+const bom = "\xef\xbb\xbf"
+
+// maxSDNameLen is the maximum length of a RFC 5424 SD-NAME.
+const maxSDNameLen = 32
+
+// escapeControl escapes the control characters of the buffer starting at start
+// and replaces invalid UTF-8 with the replacement character.
+func escapeControl(buf []byte, start int, mode EscapeMode) []byte {
+	if mode == EscapeNone || !needsEscaping(buf[start:]) {
+		return buf
+	}
+
+	// The tail is rewritten into the very same buffer, which can only grow,
+	// so it is copied aside first.
+	tail := append([]byte(nil), buf[start:]...)
+	buf = buf[:start]
+	for i := 0; i < len(tail); {
+		c, size := utf8.DecodeRune(tail[i:])
+		switch {
+		case c == utf8.RuneError && size == 1:
+			buf = utf8.AppendRune(buf, utf8.RuneError)
+		case c < ' ' || c == 0x7f:
+			buf = appendEscapedControl(buf, byte(c), mode)
+		default:
+			buf = append(buf, tail[i:i+size]...)
+		}
+		i += size
+	}
+
+	return buf
+}
+
+// needsEscaping reports whether b contains control characters or invalid
+// UTF-8.
+func needsEscaping(b []byte) bool {
+	for _, c := range b {
+		if c < ' ' || c == 0x7f {
+			return true
+		}
+	}
+
+	return !utf8.Valid(b)
+}
+
+// appendEscapedControl adds the control character escaped in the given style.
+func appendEscapedControl(buf []byte, c byte, mode EscapeMode) []byte {
+	if mode == EscapeOctal {
+		// rsyslog's style, which writes a new line as #012.
+		return append(buf, '#', '0'+c>>6, '0'+c>>3&7, '0'+c&7)
+	}
+
+	switch c {
+	case '\n':
+		return append(buf, '\\', 'n')
+	case '\r':
+		return append(buf, '\\', 'r')
+	case '\t':
+		return append(buf, '\\', 't')
+	default:
+		const hex = "0123456789abcdef"
+		return append(buf, '\\', 'x', hex[c>>4], hex[c&0xf])
+	}
+}
+
+// sdName returns the key repaired to be a valid SD-NAME, which is made of at
+// most 32 printable US-ASCII characters except for '=', ' ', ']' and '"'.
+func sdName(key string, mode SDNameMode) string {
+	if validSDName(key) {
+		return key
+	}
+
+	b := make([]byte, 0, len(key))
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case isSDNameChar(c) && (mode == SDNameReplace || c != '%'):
+			b = append(b, c)
+		case mode == SDNameEscape:
+			const hex = "0123456789ABCDEF"
+			b = append(b, '%', hex[c>>4], hex[c&0xf])
+		default:
+			b = append(b, '_')
+		}
+	}
+	if len(b) > maxSDNameLen {
+		b = b[:maxSDNameLen]
+		// Only escapes start with '%', which must not be cut.
+		if i := bytes.LastIndexByte(b, '%'); mode == SDNameEscape && i >= maxSDNameLen-2 {
+			b = b[:i]
+		}
+	}
+
+	return string(b)
+}
+
+// validSDName reports whether the key is a valid SD-NAME.
+func validSDName(key string) bool {
+	if key == "" || len(key) > maxSDNameLen {
+		return false
+	}
+
+	for i := 0; i < len(key); i++ {
+		if !isSDNameChar(key[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSDNameChar reports whether c may be a part of a SD-NAME.
+func isSDNameChar(c byte) bool {
+	return c > ' ' && c <= '~' && c != '=' && c != ']' && c != '"'
+}
+
+// validHeaderField reports whether s is a valid RFC 5424 header field of the
+// maximum length.
+func validHeaderField(s string, maxLen int) bool {
+	if len(s) > maxLen {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '!' || s[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validateHeader checks the header fields that don't change between records
+// against the limits of the RFC 5424 format, which the other formats don't
+// have.
+func validateHeader(format Format, hostname, appName, procID string) error {
+	if format != FormatRFC5424 {
+		return nil
+	}
+
+	for _, f := range [...]struct {
+		name, value string
+		maxLen      int
+	}{
+		{"hostname", hostname, 255},
+		{"app name", appName, 48},
+		{"process ID", procID, 128},
+	} {
+		if !validHeaderField(f.value, f.maxLen) {
+			return fmt.Errorf("%w: invalid %s %q", ErrInvalid, f.name, f.value)
+		}
+	}
+
+	return nil
+}
+
+// validateRecord checks whether the record can be written in the format
+// without any repair. The message ID and the attribute keys are only checked
+// in the RFC 5424 format, whose header fields and SD-NAMEs are limited.
+func validateRecord(r slog.Record, format Format, opts formatOptions) error {
+	rfc5424 := format == FormatRFC5424
+	if id := recordMsgID(r, opts); rfc5424 && !validHeaderField(id, 32) {
+		return fmt.Errorf("%w: invalid message ID %q", ErrInvalid, id)
+	}
+
+	for i := 0; i < len(r.Message); {
+		c, size := utf8.DecodeRuneInString(r.Message[i:])
+		if c == utf8.RuneError && size == 1 {
+			return fmt.Errorf("%w: invalid UTF-8 in message at byte %d", ErrInvalid, i)
+		}
+		if (c < ' ' || c == 0x7f) && (c != '\n' || i != len(r.Message)-1) {
+			return fmt.Errorf("%w: control character %s in message", ErrInvalid, strconv.QuoteRune(c))
+		}
+		i += size
+	}
+
+	var err error
+	flattenAttrs(r, opts, false, func(key string, v slog.Value) {
+		if err != nil || key == MsgIDKey {
+			return
+		}
+		if _, ok := v.Any().(SDElement); ok {
+			return
+		}
+		if rfc5424 && !validSDName(key) {
+			err = fmt.Errorf("%w: invalid attribute key %q", ErrInvalid, key)
+			return
+		}
+		if v.Kind() == slog.KindString && !utf8.ValidString(v.String()) {
+			err = fmt.Errorf("%w: invalid UTF-8 in attribute %q", ErrInvalid, key)
+		}
+	})
+
+	return err
+}
@@ -0,0 +1,185 @@
+package slogsyslog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscapeControl(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		in   string
+		mode EscapeMode
+		want string
+	}{
+		{
+			name: "Clean",
+			in:   "pre: a message",
+			want: "pre: a message",
+		},
+		{
+			name: "Octal",
+			in:   "pre: forged\n<0>Jan  1 00:00:00 evil: x\t\x7f",
+			want: "pre: forged#012<0>Jan  1 00:00:00 evil: x#011#177",
+		},
+		{
+			name: "Backslash",
+			in:   "pre: a\nb\r\tc\x00",
+			mode: EscapeBackslash,
+			want: `pre: a\nb\r\tc\x00`,
+		},
+		{
+			name: "InvalidUTF8",
+			in:   "pre: a\xffb ü",
+			want: "pre: a\ufffdb ü",
+		},
+		{
+			name: "None",
+			in:   "pre: a\nb\xff",
+			mode: EscapeNone,
+			want: "pre: a\nb\xff",
+		},
+	}
+
+	for _, tc := range testCases {
+		if got := string(escapeControl([]byte(tc.in), len("pre: "), tc.mode)); got != tc.want {
+			t.Errorf("%s: escapeControl(%q, 5, %s) = %q; want %q", tc.name, tc.in, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestSDName(t *testing.T) {
+	testCases := [...]struct {
+		key  string
+		mode SDNameMode
+		want string
+	}{
+		{"valid.key", SDNameReplace, "valid.key"},
+		{`x = y]"`, SDNameReplace, "x___y__"},
+		{"ü", SDNameReplace, "__"},
+		{`x = y%`, SDNameEscape, "x%20%3D%20y%25"},
+		{strings.Repeat("k", 40), SDNameReplace, strings.Repeat("k", 32)},
+		{strings.Repeat("k", 30) + "=", SDNameEscape, strings.Repeat("k", 30)},
+		{strings.Repeat("k", 31) + "=", SDNameEscape, strings.Repeat("k", 31)},
+		{strings.Repeat("k", 29) + "=k", SDNameEscape, strings.Repeat("k", 29) + "%3D"},
+		{"", SDNameReplace, ""},
+	}
+
+	for _, tc := range testCases {
+		if got := sdName(tc.key, tc.mode); got != tc.want {
+			t.Errorf("sdName(%q, %s) = %q; want %q", tc.key, tc.mode, got, tc.want)
+		}
+	}
+}
+
+func TestValidateRecord(t *testing.T) {
+	testCases := [...]struct {
+		name   string
+		format Format
+		msg    string
+		attrs  []slog.Attr
+		valid  bool
+	}{
+		{
+			name:  "Valid",
+			msg:   "a message\n",
+			attrs: []slog.Attr{slog.String("a", "ü"), slog.Group("g", slog.Int("b", 1))},
+			valid: true,
+		},
+		{
+			name: "ControlCharacter",
+			msg:  "a\nmessage",
+		},
+		{
+			name: "InvalidUTF8",
+			msg:  "a\xff",
+		},
+		{
+			name:   "InvalidKey",
+			format: FormatRFC5424,
+			msg:    "a message",
+			attrs:  []slog.Attr{slog.Int("x = y", 1)},
+		},
+		{
+			name:   "KeyOfOtherFormat",
+			format: FormatCEE,
+			msg:    "a message",
+			attrs:  []slog.Attr{slog.Int("x = y.z", 1)},
+			valid:  true,
+		},
+		{
+			name:  "InvalidValue",
+			msg:   "a message",
+			attrs: []slog.Attr{slog.String("a", "\xff")},
+		},
+		{
+			name:   "InvalidMsgID",
+			format: FormatRFC5424,
+			msg:    "a message",
+			attrs:  []slog.Attr{slog.String(MsgIDKey, "a b")},
+		},
+		{
+			name:   "MsgIDOfOtherFormat",
+			format: FormatGo,
+			msg:    "a message",
+			attrs:  []slog.Attr{slog.String(MsgIDKey, "a b")},
+			valid:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		r := slog.NewRecord(testTime, slog.LevelInfo, tc.msg, 0)
+		r.AddAttrs(tc.attrs...)
+
+		err := validateRecord(r, tc.format, formatOptions{})
+		if tc.valid && err != nil {
+			t.Errorf("%s: validateRecord() = %v; want nil", tc.name, err)
+		}
+		if !tc.valid && !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: validateRecord() = %v; want %v", tc.name, err, ErrInvalid)
+		}
+	}
+}
+
+func TestSyslogHandler_Strict(t *testing.T) {
+	h := newTestHandler(t, &Options{Strict: true, Tag: "test"})
+
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "forged\n<0>message", 0)
+	if err := h.Handle(context.Background(), r); !errors.Is(err, ErrInvalid) {
+		t.Errorf("Handle(%v) = %v; want %v", r, err, ErrInvalid)
+	}
+
+	r = slog.NewRecord(time.Now(), slog.LevelInfo, "a message", 0)
+	if err := h.Handle(context.Background(), r); err != nil {
+		t.Errorf("Handle(%v) = %v; want nil", r, err)
+	}
+}
+
+func TestNew_Strict(t *testing.T) {
+	opts := &Options{Network: "udp", Address: "127.0.0.1:514", Format: FormatRFC5424, Strict: true, Tag: "my app"}
+	if _, err := New(opts); !errors.Is(err, ErrInvalid) {
+		t.Errorf("New() with tag %q = %v; want %v", opts.Tag, err, ErrInvalid)
+	}
+
+	// The limits of the RFC 5424 header fields don't apply to other formats.
+	opts.Format = FormatCEE
+	h, err := New(opts)
+	if err != nil {
+		t.Fatalf("New() with tag %q in %s = %v; want nil", opts.Tag, opts.Format, err)
+	}
+	h.Close()
+}
+
+func TestRFC5424Format_BOM(t *testing.T) {
+	opts := formatOptions{BOM: true, ProcID: "1"}
+	r := slog.NewRecord(testTime, slog.LevelInfo, "a\nmessage", 0)
+
+	got := string(rfc5424Format(context.Background(), nil, r, opts))
+	if want := " - - " + bom + "a#012message"; !strings.HasSuffix(got, want) {
+		t.Errorf("rfc5424Format(ctx, nil, %v, %v) = %q; want suffix %q", r, opts, got, want)
+	}
+}
@@ -0,0 +1,288 @@
+package slogsyslog
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSignatureBlockSize is the default maximum number of message
+	// hashes in a signature block, which keeps it within 2048 octets.
+	DefaultSignatureBlockSize = 25
+
+	// DefaultSignatureInterval is the default maximum time a message waits
+	// for the signature block holding its hash.
+	DefaultSignatureInterval = 30 * time.Second
+
+	// DefaultCertificateInterval is the default interval of the certificate
+	// blocks.
+	DefaultCertificateInterval = time.Hour
+
+	// maxSignatureBlockSize is the maximum number of message hashes in a
+	// signature block allowed by RFC 5848.
+	maxSignatureBlockSize = 99
+
+	// certificateFragmentSize is the maximum size of the fragments of the
+	// payload block sent in certificate blocks.
+	certificateFragmentSize = 512
+)
+
+// SigningOptions sets the signing of messages as described in RFC 5848, which
+// lets a [Verifier] detect messages that were lost, inserted or tampered with.
+//
+// The messages' hashes are sent in signature blocks, which are messages with an
+// ssign structured data element signed by the key. The key itself is sent in
+// certificate blocks, which are messages with an ssign-cert element. Unlike
+// RFC 5848, which only defines OpenPGP DSA signatures, the signatures are those
+// of the Signer with a SHA-256 digest, which may be an ECDSA, Ed25519 or RSA
+// PKCS #1 v1.5 key. The blocks name these signature schemes with private
+// numbers, so only a [Verifier] can check them.
+type SigningOptions struct {
+	// Signer signs the blocks.
+	Signer crypto.Signer
+
+	// Certificate is the DER encoded X.509 certificate of the key sent in
+	// certificate blocks. The public key alone is sent when nil.
+	Certificate []byte
+
+	// RebootSessionID identifies the session of the sender, which must be
+	// increased every time it restarts and be persisted in between. Zero
+	// means that it isn't, in which case RFC 5848 requires a new key for
+	// every session.
+	RebootSessionID uint64
+
+	// BlockSize is the maximum number of message hashes in a signature block,
+	// at most 99. It defaults to DefaultSignatureBlockSize.
+	BlockSize int
+
+	// BlockInterval is the maximum time a message waits for the signature
+	// block holding its hash. It defaults to DefaultSignatureInterval.
+	BlockInterval time.Duration
+
+	// CertificateInterval is the interval of the certificate blocks, which
+	// are also sent when the handler is created. It defaults to
+	// DefaultCertificateInterval.
+	CertificateInterval time.Duration
+}
+
+// signatureVersion returns the VER parameter of the blocks signed by the key,
+// or an empty string if it isn't supported: version 01 of the protocol, SHA-256
+// hashes and the signature scheme of the key. RFC 5848 only registers scheme 1,
+// for OpenPGP DSA, so 7 for ECDSA, 8 for Ed25519 and 9 for RSA are private.
+func signatureVersion(key crypto.PublicKey) string {
+	switch key.(type) {
+	case *ecdsa.PublicKey:
+		return "0127"
+	case ed25519.PublicKey:
+		return "0128"
+	case *rsa.PublicKey:
+		return "0129"
+	default:
+		return ""
+	}
+}
+
+// signer hashes the messages written and creates the signature and certificate
+// blocks. Its methods are called by the writer holding its lock.
+type signer struct {
+	opts SigningOptions
+
+	// version is the VER parameter of the blocks.
+	version string
+
+	// format formats a block message holding the element.
+	format func(e SDElement) []byte
+
+	// payload is the payload block sent in certificate blocks.
+	payload string
+
+	// gbc is the global block counter of the last block and next is the
+	// number of the next message.
+	gbc  uint64
+	next uint64
+
+	// hashes are the encoded hashes of the messages since the last signature
+	// block.
+	hashes []string
+
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+// newSigner returns a signer of the options with their defaults, whose blocks
+// are formatted by format.
+func newSigner(opts SigningOptions, format func(e SDElement) []byte) (*signer, error) {
+	if opts.Signer == nil {
+		return nil, errors.New("slogsyslog: missing signer")
+	}
+	version := signatureVersion(opts.Signer.Public())
+	if version == "" {
+		return nil, errors.New("slogsyslog: unsupported signing key")
+	}
+
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultSignatureBlockSize
+	} else if opts.BlockSize > maxSignatureBlockSize {
+		return nil, errors.New("slogsyslog: signature block size too large")
+	}
+	if opts.BlockInterval <= 0 {
+		opts.BlockInterval = DefaultSignatureInterval
+	}
+	if opts.CertificateInterval <= 0 {
+		opts.CertificateInterval = DefaultCertificateInterval
+	}
+
+	blobType, blob := byte('C'), opts.Certificate
+	if blob == nil {
+		var err error
+		if blob, err = x509.MarshalPKIXPublicKey(opts.Signer.Public()); err != nil {
+			return nil, err
+		}
+		blobType = 'K'
+	}
+
+	return &signer{
+		opts:    opts,
+		version: version,
+		format:  format,
+		payload: time.Now().UTC().Format(time.RFC3339) + " " + string(blobType) + " " + base64.StdEncoding.EncodeToString(blob),
+		next:    1,
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// add adds the hash of the message written, reporting whether the signature
+// block is full.
+func (s *signer) add(b []byte) bool {
+	sum := sha256.Sum256(b)
+	s.hashes = append(s.hashes, base64.StdEncoding.EncodeToString(sum[:]))
+
+	return len(s.hashes) >= s.opts.BlockSize
+}
+
+// signatureBlock returns the signature block of the hashes added since the last
+// one or nil if there are none.
+func (s *signer) signatureBlock() ([]byte, error) {
+	if len(s.hashes) == 0 {
+		return nil, nil
+	}
+
+	fmn := s.next
+	s.gbc++
+	s.next += uint64(len(s.hashes))
+	hb := make([]byte, 0, len(s.hashes)*45)
+	for i, h := range s.hashes {
+		if i > 0 {
+			hb = append(hb, ' ')
+		}
+		hb = append(hb, h...)
+	}
+	cnt := len(s.hashes)
+	s.hashes = s.hashes[:0]
+
+	return s.sign(SDElement{ID: "ssign", Params: []SDParam{
+		{"VER", s.version},
+		{"RSID", strconv.FormatUint(s.opts.RebootSessionID, 10)},
+		{"SG", "0"},
+		{"SPRI", "0"},
+		{"GBC", strconv.FormatUint(s.gbc, 10)},
+		{"FMN", strconv.FormatUint(fmn, 10)},
+		{"CNT", strconv.Itoa(cnt)},
+		{"HB", string(hb)},
+	}})
+}
+
+// certificateBlocks returns the certificate blocks holding the fragments of
+// the payload block.
+func (s *signer) certificateBlocks() ([][]byte, error) {
+	var blocks [][]byte
+	for i := 0; i < len(s.payload); i += certificateFragmentSize {
+		frag := s.payload[i:min(i+certificateFragmentSize, len(s.payload))]
+		b, err := s.sign(SDElement{ID: "ssign-cert", Params: []SDParam{
+			{"VER", s.version},
+			{"RSID", strconv.FormatUint(s.opts.RebootSessionID, 10)},
+			{"SG", "0"},
+			{"SPRI", "0"},
+			{"TBPL", strconv.Itoa(len(s.payload))},
+			{"INDEX", strconv.Itoa(i + 1)},
+			{"FLEN", strconv.Itoa(len(frag))},
+			{"FRAG", frag},
+		}})
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, b)
+	}
+
+	return blocks, nil
+}
+
+// sign formats the block message of the element with an empty SIGN parameter
+// and fills it with the signature of the message.
+func (s *signer) sign(e SDElement) ([]byte, error) {
+	e.Params = append(e.Params, SDParam{"SIGN", ""})
+	b := s.format(e)
+
+	var (
+		sig []byte
+		err error
+	)
+	if _, ok := s.opts.Signer.Public().(ed25519.PublicKey); ok {
+		sig, err = s.opts.Signer.Sign(rand.Reader, b, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(b)
+		sig, err = s.opts.Signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The message ends with the empty SIGN parameter and the element.
+	b = b[:len(b)-len(`"]`)]
+	b = append(b, base64.StdEncoding.EncodeToString(sig)...)
+
+	return append(b, `"]`...), nil
+}
+
+// start sends the signature blocks every block interval and the certificate
+// blocks every certificate interval with the writer until closed.
+func (s *signer) start(w *writer) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		blocks := time.NewTicker(s.opts.BlockInterval)
+		defer blocks.Stop()
+		certs := time.NewTicker(s.opts.CertificateInterval)
+		defer certs.Stop()
+		for {
+			select {
+			case <-blocks.C:
+				w.sendSignature()
+			case <-certs.C:
+				w.sendCertificate()
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// close stops sending the blocks.
+func (s *signer) close() {
+	s.stop.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+}
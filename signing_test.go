@@ -0,0 +1,188 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"log/slog"
+	"math/big"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signedMessages writes n records with a handler signing them with the options
+// and returns the messages received, including the blocks.
+func signedMessages(t *testing.T, opts SigningOptions, n int) [][]byte {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := New(&Options{Network: "udp", Address: pc.LocalAddr().String(), Format: FormatRFC5424, Signing: &opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "message "+strconv.Itoa(i), 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var msgs [][]byte
+	b := make([]byte, 64<<10)
+	for {
+		pc.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+		m, _, err := pc.ReadFrom(b)
+		if err != nil {
+			break
+		}
+		msgs = append(msgs, bytes.Clone(b[:m]))
+	}
+
+	return msgs
+}
+
+func TestSyslogHandler_Signing(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "slogsyslog"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, rsaKey.Public(), rsaKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The payload block holds the time, the type of the key blob and the
+	// certificate in base64.
+	payload := len("2006-01-02T15:04:05Z C ") + base64.StdEncoding.EncodedLen(len(cert))
+	certBlocks := (payload + certificateFragmentSize - 1) / certificateFragmentSize
+	if certBlocks < 2 {
+		t.Fatalf("Certificate blocks = %d; want fragments", certBlocks)
+	}
+
+	testCases := [...]struct {
+		name   string
+		opts   SigningOptions
+		key    crypto.PublicKey
+		ver    string
+		blocks int
+	}{
+		{name: "ECDSA", opts: SigningOptions{Signer: ecKey}, key: ecKey.Public(), ver: "0127", blocks: 1},
+		{name: "Ed25519", opts: SigningOptions{Signer: edKey, RebootSessionID: 3}, ver: "0128", blocks: 1},
+		{name: "RSACertificate", opts: SigningOptions{Signer: rsaKey, Certificate: cert}, ver: "0129", blocks: certBlocks},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.opts.BlockSize = 3
+			msgs := signedMessages(t, tc.opts, 5)
+			// The certificate blocks, 3 records, a signature block, 2 records
+			// and the last signature block.
+			if want := tc.blocks + 7; len(msgs) != want {
+				t.Fatalf("Messages = %d; want %d", len(msgs), want)
+			}
+			if !bytes.Contains(msgs[0], []byte("[ssign-cert VER=\""+tc.ver+"\" RSID=\""+strconv.FormatUint(tc.opts.RebootSessionID, 10)+"\"")) {
+				t.Errorf("Message = %s; want a certificate block", msgs[0])
+			}
+			if !bytes.Contains(msgs[tc.blocks+3], []byte(`GBC="1" FMN="1" CNT="3" HB="`)) {
+				t.Errorf("Message = %s; want the first signature block", msgs[tc.blocks+3])
+			}
+
+			v := NewVerifier(tc.key)
+			for _, m := range msgs {
+				v.Add(m)
+			}
+			if r := v.Result(); !r.OK() || r.Verified != 5 {
+				t.Errorf("Result() = %+v; want 5 verified", r)
+			}
+		})
+	}
+}
+
+func TestSyslogHandler_Signing_Interval(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := New(&Options{
+		Network: "udp",
+		Address: pc.LocalAddr().String(),
+		Format:  FormatRFC5424,
+		Signing: &SigningOptions{Signer: key, BlockInterval: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "a message", 0))
+
+	b := make([]byte, 64<<10)
+	for i := 0; i < 3; i++ {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 && !bytes.Contains(b[:n], []byte(`[ssign VER="0127" RSID="0" SG="0" SPRI="0" GBC="1" FMN="1" CNT="1"`)) {
+			t.Errorf("Message = %s; want a signature block", b[:n])
+		}
+	}
+}
+
+func TestNew_SigningInvalid(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, opts := range [...]*Options{
+		{Format: FormatRFC3164, Signing: &SigningOptions{Signer: key}},
+		{Format: FormatRFC5424, Signing: &SigningOptions{}},
+		{Format: FormatRFC5424, Signing: &SigningOptions{Signer: key, BlockSize: 100}},
+	} {
+		opts.Network, opts.Address = "udp", "127.0.0.1:514"
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) = nil; want error", opts.Signing)
+		}
+	}
+}
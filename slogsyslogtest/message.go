@@ -0,0 +1,92 @@
+package slogsyslogtest
+
+import (
+	"testing"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+// Attr is a single attribute received as part of a syslog message.
+type Attr struct {
+	// Key of the attribute including any group prefixes.
+	Key string
+
+	// Value of the attribute with all escaping removed.
+	Value string
+}
+
+// Message is a syslog message received by the [Server].
+type Message struct {
+	slogsyslog.Message
+
+	// Raw is the message exactly as it was received, without the framing.
+	Raw string
+
+	// Attrs are the message's structured data parameters in the order they
+	// were sent. Keys of parameters belonging to an element with an ID are
+	// prefixed with it and a dot.
+	Attrs []Attr
+
+	// Err is set when the message could not be parsed. Only Raw is valid then.
+	Err error
+}
+
+// Attr returns the value of the first attribute with the given key.
+func (m Message) Attr(key string) (string, bool) {
+	for _, a := range m.Attrs {
+		if a.Key == key {
+			return a.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// AssertAttr fails the test if the message does not have an attribute with the
+// given key and value.
+func (m Message) AssertAttr(tb testing.TB, key, want string) {
+	tb.Helper()
+
+	got, ok := m.Attr(key)
+	if !ok {
+		tb.Errorf("message %q has no attribute %q", m.Raw, key)
+		return
+	}
+	if got != want {
+		tb.Errorf("message %q attribute %q = %q; want %q", m.Raw, key, got, want)
+	}
+}
+
+// AssertNoAttr fails the test if the message has an attribute with the given
+// key.
+func (m Message) AssertNoAttr(tb testing.TB, key string) {
+	tb.Helper()
+
+	if got, ok := m.Attr(key); ok {
+		tb.Errorf("message %q attribute %q = %q; want none", m.Raw, key, got)
+	}
+}
+
+// parseMessage parses a received message.
+func parseMessage(raw []byte) Message {
+	m := Message{Raw: string(raw)}
+
+	p, err := slogsyslog.ParseMessage(raw)
+	if err != nil {
+		m.Err = err
+		return m
+	}
+	m.Message = *p
+
+	for _, e := range p.StructuredData {
+		for _, sp := range e.Params {
+			a := Attr{Key: sp.Name, Value: sp.Value}
+			if e.ID != "" {
+				a.Key = e.ID + "." + sp.Name
+			}
+			m.Attrs = append(m.Attrs, a)
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,140 @@
+package slogsyslogtest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// relpOffers are the offers of the response to the open command.
+const relpOffers = "200 OK\nrelp_version=0\nrelp_software=slogsyslogtest\ncommands=syslog"
+
+// relpConn is a RELP session accepted by the server.
+type relpConn struct {
+	net.Conn
+
+	// mu serializes the responses.
+	mu sync.Mutex
+
+	// held are the commands received while acknowledgements are held. They
+	// are protected by the server's lock.
+	held []relpCommand
+}
+
+// relpCommand is a command waiting for its acknowledgement.
+type relpCommand struct {
+	txnr    int
+	command string
+	data    []byte
+}
+
+// HoldAcks stops acknowledging the messages received over RELP, which aren't
+// stored either, until [Server.ReleaseAcks] is called. The held messages of the
+// connections dropped in the meantime are lost, just like those of a server
+// restarting before processing them.
+func (s *Server) HoldAcks() {
+	s.mu.Lock()
+	s.holdAcks = true
+	s.mu.Unlock()
+}
+
+// ReleaseAcks stores and acknowledges the messages held by [Server.HoldAcks]
+// and resumes acknowledging them as they are received.
+func (s *Server) ReleaseAcks() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.holdAcks = false
+	for _, c := range s.relpConns {
+		for _, cmd := range c.held {
+			s.processLocked(c, cmd)
+		}
+		c.held = nil
+	}
+}
+
+// readRELP reads RELP commands from a connection until it is closed.
+func (s *Server) readRELP(c *relpConn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c.Conn)
+		delete(s.relpConns, c.Conn)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	r := bufio.NewReader(c)
+	for s.waitStall() {
+		cmd, err := readRELPCommand(r)
+		if err != nil {
+			return
+		}
+		if cmd.command == "open" {
+			c.respond(cmd.txnr, relpOffers)
+			continue
+		}
+
+		s.mu.Lock()
+		if s.holdAcks {
+			c.held = append(c.held, cmd)
+		} else {
+			s.processLocked(c, cmd)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// processLocked stores the message of a syslog command and acknowledges the
+// command. The caller must hold the lock.
+func (s *Server) processLocked(c *relpConn, cmd relpCommand) {
+	switch cmd.command {
+	case "syslog":
+		s.addLocked(cmd.data)
+		c.respond(cmd.txnr, "200 OK")
+	case "close":
+		c.respond(cmd.txnr, "200 OK")
+		c.Close()
+	default:
+		c.respond(cmd.txnr, "500 unknown command")
+	}
+}
+
+// respond sends the response to the command with the transaction number.
+func (c *relpConn) respond(txnr int, data string) {
+	c.mu.Lock()
+	fmt.Fprintf(c, "%d rsp %d %s\n", txnr, len(data), data)
+	c.mu.Unlock()
+}
+
+// readRELPCommand reads a RELP frame.
+func readRELPCommand(r *bufio.Reader) (relpCommand, error) {
+	var (
+		cmd relpCommand
+		n   int
+	)
+	if _, err := fmt.Fscan(r, &cmd.txnr, &cmd.command, &n); err != nil {
+		return cmd, err
+	}
+	if n < 0 || n > 1<<20 {
+		return cmd, fmt.Errorf("invalid RELP data length %d", n)
+	}
+
+	// The data length is followed by a space and the data, if any, and the
+	// frame ends with a new line.
+	size := 1
+	if n > 0 {
+		size = n + 2
+	}
+	b := make([]byte, size)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return cmd, err
+	}
+	if n > 0 {
+		cmd.data = b[1 : n+1]
+	}
+
+	return cmd, nil
+}
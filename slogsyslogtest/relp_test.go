@@ -0,0 +1,149 @@
+package slogsyslogtest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+func TestServer_RELP(t *testing.T) {
+	s := NewServer(t, "relp")
+	h := s.Handler(nil)
+
+	for _, msg := range [...]string{"first", "second", "third"} {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatalf("Handle(%q) = %v; want nil", msg, err)
+		}
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+
+	msgs := s.Messages()
+	if len(msgs) != 3 || msgs[0].Text != "first" || msgs[2].Text != "third" {
+		t.Fatalf("Messages = %+v; want first, second and third", msgs)
+	}
+	if st := h.Stats(); st.Written != 3 || st.WriteErrors != 0 {
+		t.Errorf("Stats() = %+v; want 3 written", st)
+	}
+}
+
+func TestServer_RELPResend(t *testing.T) {
+	s := NewServer(t, "relp")
+	h := s.Handler(&slogsyslog.Options{RELP: &slogsyslog.RELPOptions{AckLevel: slog.LevelWarn}})
+
+	s.HoldAcks()
+	for _, msg := range [...]string{"first", "second"} {
+		if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0)); err != nil {
+			t.Fatalf("Handle(%q) = %v; want nil", msg, err)
+		}
+	}
+
+	// The server loses the messages it didn't acknowledge.
+	s.DropConnections()
+	s.ReleaseAcks()
+
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "third", 0)); err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+
+	msgs := s.Messages()
+	if len(msgs) != 3 || msgs[0].Text != "first" || msgs[1].Text != "second" || msgs[2].Text != "third" {
+		t.Fatalf("Messages = %+v; want first, second and third", msgs)
+	}
+	if st := h.Stats(); st.Written != 3 || st.Reconnects != 1 {
+		t.Errorf("Stats() = %+v; want 3 written and 1 reconnect", st)
+	}
+}
+
+func TestServer_RELPAckLevel(t *testing.T) {
+	s := NewServer(t, "relp")
+	h := s.Handler(&slogsyslog.Options{RELP: &slogsyslog.RELPOptions{AckLevel: slog.LevelWarn}})
+
+	s.HoldAcks()
+	done := make(chan error)
+	go func() {
+		done <- h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelWarn, "audit", 0))
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("Handle() = %v before the acknowledgement", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	s.ReleaseAcks()
+	if err := <-done; err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+	if msgs := s.Messages(); len(msgs) != 1 || msgs[0].Text != "audit" {
+		t.Errorf("Messages = %+v; want audit", msgs)
+	}
+}
+
+func TestServer_RELPAckTimeout(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		opts slogsyslog.RELPOptions
+		// records are written before the one timing out.
+		records int
+	}{
+		{
+			name: "AckLevel",
+			opts: slogsyslog.RELPOptions{AckTimeout: 50 * time.Millisecond, AckLevel: slog.LevelInfo},
+		},
+		{
+			name:    "WindowFull",
+			opts:    slogsyslog.RELPOptions{AckTimeout: 50 * time.Millisecond, WindowSize: 1},
+			records: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewServer(t, "relp")
+			h := s.Handler(&slogsyslog.Options{RELP: &tc.opts})
+
+			s.HoldAcks()
+			for i := 0; i < tc.records; i++ {
+				if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "waiting", 0)); err != nil {
+					t.Fatalf("Handle() = %v; want nil", err)
+				}
+			}
+			// It reconnects once before giving up.
+			err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "timeout", 0))
+			if !errors.Is(err, os.ErrDeadlineExceeded) {
+				t.Fatalf("Handle() = %v; want %v", err, os.ErrDeadlineExceeded)
+			}
+			if st := h.Stats(); st.Reconnects != 1 || st.Timeouts != 2 || st.Dropped[slogsyslog.DropWriteError] != 1 {
+				t.Errorf("Stats() = %+v; want 1 reconnect, 2 timeouts and 1 dropped", st)
+			}
+
+			s.ReleaseAcks()
+			if err := h.Close(); err != nil {
+				t.Errorf("Close() = %v; want nil", err)
+			}
+		})
+	}
+}
+
+func TestServer_RELPClose(t *testing.T) {
+	s := NewServer(t, "relp")
+	h := s.Handler(&slogsyslog.Options{RELP: &slogsyslog.RELPOptions{AckTimeout: 50 * time.Millisecond}})
+
+	s.HoldAcks()
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0)); err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+	if err := h.Close(); err == nil {
+		t.Error("Close() = <nil>; want error for the message not acknowledged")
+	}
+}
@@ -0,0 +1,494 @@
+// Package slogsyslogtest implements an in-process fake syslog server for
+// testing code that logs through [slogsyslog.SyslogHandler].
+package slogsyslogtest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+// DefaultTimeout is the default duration [Server.Wait] waits for messages.
+const DefaultTimeout = 5 * time.Second
+
+// Server is a fake syslog server listening on a local socket. It captures and
+// parses all received messages.
+type Server struct {
+	// Timeout is the maximum duration [Server.Wait] waits for messages. If
+	// zero, [DefaultTimeout] is used.
+	Timeout time.Duration
+
+	// tb is the test the server belongs to.
+	tb testing.TB
+
+	// network the server listens on as passed to the constructor.
+	network string
+
+	// address the server listens on.
+	address string
+
+	// serverTLS is the server's TLS configuration. TLS is not used when nil.
+	serverTLS *tls.Config
+
+	// clientTLS is the TLS configuration the clients should use.
+	clientTLS *tls.Config
+
+	// wg tracks running goroutines.
+	wg sync.WaitGroup
+
+	// mu protects the fields below.
+	mu sync.Mutex
+
+	// ln is the listener for stream oriented networks.
+	ln net.Listener
+
+	// pc is the connection for datagram oriented networks.
+	pc net.PacketConn
+
+	// conns are currently accepted connections.
+	conns map[net.Conn]struct{}
+
+	// relpConns are the RELP sessions of the accepted connections.
+	relpConns map[net.Conn]*relpConn
+
+	// holdAcks indicates whether RELP acknowledgements are held.
+	holdAcks bool
+
+	// msgs are all the received messages.
+	msgs []Message
+
+	// notify is closed and replaced whenever a message is received.
+	notify chan struct{}
+
+	// stall, when not nil, blocks reading until closed.
+	stall chan struct{}
+
+	// done is closed once the server is closed.
+	done chan struct{}
+
+	// closed indicates whether the server was closed.
+	closed bool
+}
+
+// NewServer starts a new fake syslog server. The network must be one of
+// "unixgram", "unix", "udp", "tcp", "tls" or "relp". UNIX sockets are created
+// in a temporary directory, while the others listen on a random loopback port.
+// The server is closed automatically when the test completes.
+func NewServer(tb testing.TB, network string) *Server {
+	tb.Helper()
+
+	s := &Server{
+		tb:        tb,
+		network:   network,
+		conns:     make(map[net.Conn]struct{}),
+		relpConns: make(map[net.Conn]*relpConn),
+		notify:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+
+	switch network {
+	case "unixgram", "unix":
+		// Socket paths are limited in length, so we can't use the test's
+		// temporary directory which includes the name of the test.
+		dir, err := os.MkdirTemp("", "slogsyslogtest")
+		if err != nil {
+			tb.Fatalf("slogsyslogtest: %s", err)
+		}
+		tb.Cleanup(func() { os.RemoveAll(dir) })
+		s.address = filepath.Join(dir, "log.sock")
+	case "udp", "tcp", "relp":
+		s.address = "127.0.0.1:0"
+	case "tls":
+		var err error
+		if s.serverTLS, s.clientTLS, err = newTLSConfigs(); err != nil {
+			tb.Fatalf("slogsyslogtest: %s", err)
+		}
+		s.address = "127.0.0.1:0"
+	default:
+		tb.Fatalf("slogsyslogtest: unsupported network %q", network)
+	}
+
+	s.mu.Lock()
+	err := s.listen()
+	s.mu.Unlock()
+	if err != nil {
+		tb.Fatalf("slogsyslogtest: %s", err)
+	}
+	tb.Cleanup(s.Close)
+
+	return s
+}
+
+// Network returns the network protocol clients should use to connect to the
+// server.
+func (s *Server) Network() string {
+	if s.network == "tls" {
+		return "tcp"
+	}
+
+	return s.network
+}
+
+// Address returns the address the server listens on.
+func (s *Server) Address() string { return s.address }
+
+// Options returns handler options pre-wired to connect to the server.
+func (s *Server) Options() slogsyslog.Options {
+	opts := slogsyslog.Options{
+		Network: s.Network(),
+		Address: s.address,
+	}
+	if s.clientTLS != nil {
+		opts.TLSConfig = s.clientTLS.Clone()
+	}
+
+	return opts
+}
+
+// Handler creates a new handler connected to the server. Any of the provided
+// options' connection settings are overridden with the server's ones.
+func (s *Server) Handler(opts *slogsyslog.Options) *slogsyslog.SyslogHandler {
+	s.tb.Helper()
+
+	o := s.Options()
+	if opts != nil {
+		c := *opts
+		c.Network, c.Address, c.TLSConfig = o.Network, o.Address, o.TLSConfig
+		o = c
+	}
+
+	h, err := slogsyslog.New(&o)
+	if err != nil {
+		s.tb.Fatalf("slogsyslogtest: %s", err)
+	}
+	s.tb.Cleanup(func() { h.Close() })
+
+	return h
+}
+
+// Messages returns all the messages received so far.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.msgs...)
+}
+
+// Reset discards all the messages received so far.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	s.msgs = nil
+	s.mu.Unlock()
+}
+
+// Wait waits until at least n messages were received and returns all of them.
+// It fails the test if that does not happen within the server's timeout. Just
+// like [testing.T.FailNow], it must be called from the test's goroutine.
+func (s *Server) Wait(n int) []Message {
+	s.tb.Helper()
+
+	msgs, err := s.WaitTimeout(n, s.timeout())
+	if err != nil {
+		s.tb.Fatalf("slogsyslogtest: %s", err)
+	}
+
+	return msgs
+}
+
+// WaitTimeout waits until at least n messages were received and returns all
+// of them. It returns an error if that does not happen within the timeout.
+func (s *Server) WaitTimeout(n int, timeout time.Duration) ([]Message, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		s.mu.Lock()
+		if len(s.msgs) >= n {
+			msgs := append([]Message(nil), s.msgs...)
+			s.mu.Unlock()
+			return msgs, nil
+		}
+		got, notify := len(s.msgs), s.notify
+		s.mu.Unlock()
+
+		select {
+		case <-notify:
+		case <-timer.C:
+			return nil, fmt.Errorf("waiting for %d message(s), got %d: %w", n, got, os.ErrDeadlineExceeded)
+		}
+	}
+}
+
+// DropConnections closes all the currently accepted connections. For datagram
+// oriented networks, the socket is recreated at the same address instead, so
+// connected clients notice the server went away.
+func (s *Server) DropConnections() {
+	s.tb.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+
+	s.closeConns()
+	if s.pc != nil {
+		s.pc.Close()
+		s.pc = nil
+		if err := s.listen(); err != nil {
+			s.tb.Errorf("slogsyslogtest: %s", err)
+		}
+	}
+}
+
+// RefuseConnections stops listening and closes all the currently accepted
+// connections until [Server.AcceptConnections] is called. Connecting or
+// writing to the server fails in the meantime.
+func (s *Server) RefuseConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeConns()
+	s.closeListener()
+}
+
+// AcceptConnections starts listening at the same address again after a call to
+// [Server.RefuseConnections].
+func (s *Server) AcceptConnections() {
+	s.tb.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed || s.ln != nil || s.pc != nil {
+		return
+	}
+	if err := s.listen(); err != nil {
+		s.tb.Errorf("slogsyslogtest: %s", err)
+	}
+}
+
+// StallReads stops reading from the connections until [Server.ResumeReads] is
+// called. Once the socket buffers fill up, clients' writes block, which is
+// useful to exercise write timeouts.
+func (s *Server) StallReads() {
+	s.mu.Lock()
+	if s.stall == nil {
+		s.stall = make(chan struct{})
+	}
+	s.mu.Unlock()
+}
+
+// ResumeReads resumes reading stalled by [Server.StallReads].
+func (s *Server) ResumeReads() {
+	s.mu.Lock()
+	if s.stall != nil {
+		close(s.stall)
+		s.stall = nil
+	}
+	s.mu.Unlock()
+}
+
+// Close stops the server and closes all of its connections.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	s.closeConns()
+	s.closeListener()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// timeout returns the duration to wait for messages.
+func (s *Server) timeout() time.Duration {
+	if s.Timeout > 0 {
+		return s.Timeout
+	}
+
+	return DefaultTimeout
+}
+
+// listen starts listening at the server's address. The caller must hold the
+// lock.
+func (s *Server) listen() error {
+	switch s.network {
+	case "unixgram", "udp":
+		if s.network == "unixgram" {
+			os.Remove(s.address)
+		}
+
+		pc, err := net.ListenPacket(s.network, s.address)
+		if err != nil {
+			return err
+		}
+		s.pc = pc
+		s.address = pc.LocalAddr().String()
+
+		s.wg.Add(1)
+		go s.readPackets(pc)
+	default:
+		network := s.network
+		if network == "tls" || network == "relp" {
+			network = "tcp"
+		}
+
+		ln, err := net.Listen(network, s.address)
+		if err != nil {
+			return err
+		}
+		s.address = ln.Addr().String()
+		if s.serverTLS != nil {
+			ln = tls.NewListener(ln, s.serverTLS)
+		}
+		s.ln = ln
+
+		s.wg.Add(1)
+		go s.accept(ln)
+	}
+
+	return nil
+}
+
+// closeListener stops listening. The caller must hold the lock.
+func (s *Server) closeListener() {
+	if s.ln != nil {
+		s.ln.Close()
+		s.ln = nil
+	}
+	if s.pc != nil {
+		s.pc.Close()
+		s.pc = nil
+		if s.network == "unixgram" {
+			os.Remove(s.address)
+		}
+	}
+}
+
+// closeConns closes all the accepted connections. The caller must hold the
+// lock.
+func (s *Server) closeConns() {
+	for c := range s.conns {
+		c.Close()
+		delete(s.conns, c)
+		delete(s.relpConns, c)
+	}
+}
+
+// readPackets reads messages from a datagram oriented connection until it is
+// closed.
+func (s *Server) readPackets(pc net.PacketConn) {
+	defer s.wg.Done()
+
+	buf := make([]byte, 64<<10)
+	for {
+		if !s.waitStall() {
+			return
+		}
+
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		s.add(buf[:n])
+	}
+}
+
+// accept accepts connections on a stream oriented listener until it is closed.
+func (s *Server) accept(ln net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			c.Close()
+			return
+		}
+		s.conns[c] = struct{}{}
+		var rc *relpConn
+		if s.network == "relp" {
+			rc = &relpConn{Conn: c}
+			s.relpConns[c] = rc
+		}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		if rc != nil {
+			go s.readRELP(rc)
+		} else {
+			go s.readStream(c)
+		}
+	}
+}
+
+// readStream reads octet counted or new line delimited messages from a stream
+// oriented connection until it is closed.
+func (s *Server) readStream(c net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, c)
+		s.mu.Unlock()
+		c.Close()
+	}()
+
+	sc := bufio.NewScanner(c)
+	sc.Split(slogsyslog.ScanMessages)
+	for s.waitStall() && sc.Scan() {
+		if len(sc.Bytes()) > 0 {
+			s.add(sc.Bytes())
+		}
+	}
+}
+
+// waitStall blocks while reading is stalled. It returns false if the server was
+// closed in the meantime.
+func (s *Server) waitStall() bool {
+	s.mu.Lock()
+	stall := s.stall
+	s.mu.Unlock()
+	if stall == nil {
+		return true
+	}
+
+	select {
+	case <-stall:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// add parses and stores a received message.
+func (s *Server) add(raw []byte) {
+	s.mu.Lock()
+	s.addLocked(raw)
+	s.mu.Unlock()
+}
+
+// addLocked is add for callers holding the lock.
+func (s *Server) addLocked(raw []byte) {
+	s.msgs = append(s.msgs, parseMessage(raw))
+	close(s.notify)
+	s.notify = make(chan struct{})
+}
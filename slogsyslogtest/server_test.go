@@ -0,0 +1,189 @@
+package slogsyslogtest
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	slogsyslog "github.com/mocheryl/slog-syslog"
+)
+
+func TestServer(t *testing.T) {
+	testCases := [...]struct {
+		name     string
+		network  string
+		hostname bool
+	}{
+		{
+			name:    "Unixgram",
+			network: "unixgram",
+		},
+		{
+			name:    "Unix",
+			network: "unix",
+		},
+		{
+			name:     "UDP",
+			network:  "udp",
+			hostname: true,
+		},
+		{
+			name:     "TCP",
+			network:  "tcp",
+			hostname: true,
+		},
+		{
+			name:     "TLS",
+			network:  "tls",
+			hostname: true,
+		},
+		{
+			name:     "RELP",
+			network:  "relp",
+			hostname: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewServer(t, tc.network)
+			h := s.Handler(&slogsyslog.Options{Facility: slogsyslog.Local3, Tag: "test"})
+
+			l := slog.New(h).With("foo", "bar").WithGroup("g")
+			l.Info("first", "a", 1)
+			l.Warn("second", "b", `qu"o`)
+
+			msgs := s.Wait(2)
+			for _, m := range msgs {
+				if m.Err != nil {
+					t.Fatalf("Message %q: %s", m.Raw, m.Err)
+				}
+				if m.Facility != slogsyslog.Local3 {
+					t.Errorf("Message.Facility = %s; want %s", m.Facility, slogsyslog.Local3)
+				}
+				if m.AppName != "test" {
+					t.Errorf("Message.AppName = %q; want %q", m.AppName, "test")
+				}
+				if m.ProcID != strconv.Itoa(os.Getpid()) {
+					t.Errorf("Message.ProcID = %q; want %d", m.ProcID, os.Getpid())
+				}
+				if tc.hostname && m.Hostname == "" {
+					t.Errorf("Message.Hostname is empty")
+				}
+				m.AssertAttr(t, "foo", "bar")
+			}
+
+			if msgs[0].Severity != 6 || msgs[0].Text != "first" {
+				t.Errorf("Message = <%d> %q; want <6> %q", msgs[0].Severity, msgs[0].Text, "first")
+			}
+			msgs[0].AssertAttr(t, "g.a", "1")
+			msgs[0].AssertNoAttr(t, "g.b")
+
+			if msgs[1].Severity != 4 || msgs[1].Text != "second" {
+				t.Errorf("Message = <%d> %q; want <4> %q", msgs[1].Severity, msgs[1].Text, "second")
+			}
+			msgs[1].AssertAttr(t, "g.b", `qu"o`)
+		})
+	}
+}
+
+func TestServer_RFC5424(t *testing.T) {
+	for _, network := range [...]string{"udp", "tcp", "unix"} {
+		network := network
+		t.Run(network, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewServer(t, network)
+			// Messages spanning multiple lines are kept as they are by octet counting.
+			h := s.Handler(&slogsyslog.Options{Format: slogsyslog.FormatRFC5424, Tag: "test", Escape: slogsyslog.EscapeNone})
+
+			slog.New(h).Info("multi\nline", "a", 1)
+
+			m := s.Wait(1)[0]
+			if m.Err != nil {
+				t.Fatalf("Message %q: %s", m.Raw, m.Err)
+			}
+			if m.Version != 1 || m.AppName != "test" || m.Text != "multi\nline" {
+				t.Errorf("Message = %d %q %q; want 1 %q %q", m.Version, m.AppName, m.Text, "test", "multi\nline")
+			}
+			m.AssertAttr(t, slogsyslog.DefaultSDID+".a", "1")
+		})
+	}
+}
+
+func TestServer_DropConnections(t *testing.T) {
+	for _, network := range [...]string{"unixgram", "unix"} {
+		network := network
+		t.Run(network, func(t *testing.T) {
+			t.Parallel()
+
+			s := NewServer(t, network)
+			h := s.Handler(nil)
+
+			if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "before", 0)); err != nil {
+				t.Fatalf("Handle() = %v; want nil", err)
+			}
+			s.Wait(1)
+
+			s.DropConnections()
+
+			if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "after", 0)); err != nil {
+				t.Fatalf("Handle() after drop = %v; want nil", err)
+			}
+			if msgs := s.Wait(2); msgs[1].Text != "after" {
+				t.Errorf("Message.Text = %q; want %q", msgs[1].Text, "after")
+			}
+		})
+	}
+}
+
+func TestServer_RefuseConnections(t *testing.T) {
+	s := NewServer(t, "unix")
+	h := s.Handler(nil)
+
+	s.RefuseConnections()
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "refused", 0)); err == nil {
+		t.Fatal("Handle() while refusing = <nil>; want error")
+	}
+
+	s.AcceptConnections()
+	if err := h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "accepted", 0)); err != nil {
+		t.Fatalf("Handle() after accepting = %v; want nil", err)
+	}
+	if msgs := s.Wait(1); msgs[0].Text != "accepted" {
+		t.Errorf("Message.Text = %q; want %q", msgs[0].Text, "accepted")
+	}
+}
+
+func TestServer_StallReads(t *testing.T) {
+	s := NewServer(t, "unixgram")
+	h := s.Handler(&slogsyslog.Options{WriteTimeout: 50 * time.Millisecond})
+
+	s.StallReads()
+
+	var err error
+	for i := 0; i < 100000 && err == nil; i++ {
+		err = h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "stalled", 0))
+	}
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Handle() while stalled = %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+
+	s.ResumeReads()
+	s.Wait(1)
+}
+
+func TestServer_WaitTimeout(t *testing.T) {
+	s := NewServer(t, "udp")
+
+	if _, err := s.WaitTimeout(1, 10*time.Millisecond); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("WaitTimeout(1, 10ms) = %v; want %v", err, os.ErrDeadlineExceeded)
+	}
+}
@@ -0,0 +1,536 @@
+package slogsyslog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultSpoolSegmentSize is the default maximum size of the spool's
+	// segment files.
+	DefaultSpoolSegmentSize = 1 << 20
+
+	// DefaultSpoolMaxSize is the default maximum size of the spool.
+	DefaultSpoolMaxSize = 64 << 20
+
+	// DefaultSpoolMaxAge is the default maximum age of the spooled messages.
+	DefaultSpoolMaxAge = 24 * time.Hour
+
+	// DefaultSpoolRetryInterval is the default interval of the attempts at
+	// replaying the spool.
+	DefaultSpoolRetryInterval = 10 * time.Second
+
+	// spoolSuffix is the suffix of the segment files, which are named after
+	// their zero-padded sequence number.
+	spoolSuffix = ".spool"
+
+	// spoolCursor is the name of the file holding the sequence number of the
+	// segment being replayed and the offset of its next message.
+	spoolCursor = "cursor"
+
+	// spoolHeaderSize is the size of the header of an entry: the size of the
+	// message, the CRC-32C of the rest of the entry and the time the message
+	// was spooled in nanoseconds since the Unix epoch.
+	spoolHeaderSize = 16
+
+	// maxSpoolEntrySize is the maximum size of a spooled message.
+	maxSpoolEntrySize = 16 << 20
+)
+
+// errSpoolCorrupt is returned when reading an entry whose CRC doesn't match.
+var errSpoolCorrupt = errors.New("slogsyslog: corrupt spool entry")
+
+// spoolTable is the CRC-32C table of the spool entries.
+var spoolTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SpoolOptions sets the spool keeping the messages that can't be written, such
+// as while the syslog server is unreachable, in segment files of a local
+// directory. They are replayed in order, with their original timestamps, as
+// soon as the handler reconnects, before any new message. The spool outlives
+// the handler, so that a handler with the same directory replays the messages
+// left by a previous process. The messages may be written twice if the process
+// stops while replaying them.
+//
+// The spooled messages are counted in the QueueDepth of [Stats] and those that
+// are discarded in its Dropped ones with [DropSpool].
+type SpoolOptions struct {
+	// Dir is the directory of the segment files, which is created if it
+	// doesn't exist. It must not be shared by handlers running concurrently.
+	Dir string
+
+	// SegmentSize is the size after which a new segment file is started. It
+	// defaults to DefaultSpoolSegmentSize and is at most MaxSize.
+	SegmentSize int64
+
+	// MaxSize is the maximum size of the segment files, beyond which the
+	// oldest ones are discarded. It defaults to DefaultSpoolMaxSize.
+	MaxSize int64
+
+	// MaxAge is the maximum age of the spooled messages, older ones being
+	// discarded. It defaults to DefaultSpoolMaxAge and the messages never
+	// expire if negative.
+	MaxAge time.Duration
+
+	// RetryInterval is the interval of the attempts at replaying the spool
+	// while no message is written. It defaults to DefaultSpoolRetryInterval.
+	RetryInterval time.Duration
+}
+
+// spoolSegment is a segment file of the spool.
+type spoolSegment struct {
+	// seq is the sequence number naming the file.
+	seq uint64
+
+	// size is the size of its valid entries.
+	size int64
+
+	// entries is the number of its entries that weren't replayed.
+	entries int64
+
+	// modTime is the time of its last entry.
+	modTime time.Time
+}
+
+// spool is an append-only queue of messages in segment files. Its methods are
+// called by the writer holding its lock.
+type spool struct {
+	opts SpoolOptions
+
+	// stats count the spooled and discarded messages.
+	stats *stats
+
+	// segments are the segment files, oldest first. The last one is appended
+	// to once active is open.
+	segments []*spoolSegment
+	active   *os.File
+
+	// reader reads the first segment from offset, where the next entry is n
+	// bytes long once read.
+	reader *os.File
+	offset int64
+	n      int64
+
+	// seq is the sequence number of the last segment.
+	seq uint64
+
+	// size is the size of all the segments and count their entries that
+	// weren't replayed.
+	size  int64
+	count int64
+
+	// moved indicates whether the cursor moved since it was saved.
+	moved bool
+
+	done chan struct{}
+	stop sync.Once
+	wg   sync.WaitGroup
+}
+
+// openSpool opens the spool of the options with their defaults, picking up the
+// messages left in its directory.
+func openSpool(opts SpoolOptions, st *stats) (*spool, error) {
+	if opts.Dir == "" {
+		return nil, errors.New("slogsyslog: missing spool directory")
+	}
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = DefaultSpoolMaxSize
+	}
+	if opts.SegmentSize <= 0 {
+		opts.SegmentSize = DefaultSpoolSegmentSize
+	}
+	opts.SegmentSize = min(opts.SegmentSize, opts.MaxSize)
+	if opts.MaxAge == 0 {
+		opts.MaxAge = DefaultSpoolMaxAge
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultSpoolRetryInterval
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o700); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(opts.Dir)
+	if err != nil {
+		return nil, err
+	}
+	cursorSeq, cursorOffset := readSpoolCursor(filepath.Join(opts.Dir, spoolCursor))
+
+	s := &spool{opts: opts, stats: st, done: make(chan struct{})}
+	// The files are sorted by name, hence by sequence number.
+	for _, f := range files {
+		name, ok := strings.CutSuffix(f.Name(), spoolSuffix)
+		if !ok {
+			continue
+		}
+		seq, err := strconv.ParseUint(name, 10, 64)
+		if err != nil {
+			continue
+		}
+		if seq < cursorSeq {
+			// Replayed, but not removed yet.
+			os.Remove(filepath.Join(opts.Dir, f.Name()))
+			continue
+		}
+
+		var from int64
+		if seq == cursorSeq {
+			from = cursorOffset
+		}
+		seg, start, err := scanSpoolSegment(s.path(seq), seq, from)
+		if err != nil {
+			return nil, err
+		}
+		if len(s.segments) == 0 {
+			s.offset = start
+		}
+		s.segments = append(s.segments, seg)
+		s.seq = seq
+		s.size += seg.size
+		s.count += seg.entries
+	}
+	// New segments follow the one of the cursor even if it was removed, so
+	// that they aren't taken as replayed.
+	s.seq = max(s.seq, cursorSeq)
+	s.stats.queued.Add(s.count)
+	s.enforce(time.Now())
+	if s.count == 0 {
+		s.removeAll()
+	}
+
+	return s, nil
+}
+
+// path returns the path of the segment file with the sequence number.
+func (s *spool) path(seq uint64) string {
+	return filepath.Join(s.opts.Dir, fmt.Sprintf("%020d%s", seq, spoolSuffix))
+}
+
+// empty reports whether all the messages were replayed.
+func (s *spool) empty() bool {
+	return s.count == 0
+}
+
+// append appends the message b to the spool.
+func (s *spool) append(b []byte) error {
+	if len(b) > maxSpoolEntrySize {
+		return errors.New("slogsyslog: message too large to spool")
+	}
+
+	now := time.Now()
+	n := int64(spoolHeaderSize + len(b))
+	if s.active == nil || s.last().size > 0 && s.last().size+n > s.opts.SegmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	entry := make([]byte, spoolHeaderSize, n)
+	binary.BigEndian.PutUint32(entry, uint32(len(b)))
+	binary.BigEndian.PutUint64(entry[8:], uint64(now.UnixNano()))
+	entry = append(entry, b...)
+	binary.BigEndian.PutUint32(entry[4:], crc32.Checksum(entry[8:], spoolTable))
+	if _, err := s.active.Write(entry); err != nil {
+		// Appending after a partial entry would misalign the next ones.
+		s.active.Close()
+		s.active = nil
+		return err
+	}
+
+	seg := s.last()
+	seg.size += n
+	seg.entries++
+	seg.modTime = now
+	s.size += n
+	s.count++
+	s.stats.queued.Add(1)
+	s.enforce(now)
+
+	return nil
+}
+
+// last returns the last segment.
+func (s *spool) last() *spoolSegment {
+	return s.segments[len(s.segments)-1]
+}
+
+// rotate starts a new segment file. The segments of a previous process are
+// never appended to, as they may end with a partial entry.
+func (s *spool) rotate() error {
+	if s.active != nil {
+		s.active.Close()
+		s.active = nil
+	}
+
+	f, err := os.OpenFile(s.path(s.seq+1), os.O_WRONLY|os.O_CREATE|os.O_EXCL|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	s.seq++
+	s.active = f
+	s.segments = append(s.segments, &spoolSegment{seq: s.seq})
+
+	return nil
+}
+
+// enforce discards the oldest segments while the spool exceeds its maximum
+// size or they are older than its maximum age. The segment appended to is
+// always kept.
+func (s *spool) enforce(now time.Time) {
+	for len(s.segments) > 1 || len(s.segments) == 1 && s.active == nil {
+		seg := s.segments[0]
+		if s.size <= s.opts.MaxSize && (s.opts.MaxAge < 0 || now.Sub(seg.modTime) <= s.opts.MaxAge) {
+			return
+		}
+		s.removeFirst()
+	}
+}
+
+// next returns the next message to replay, or nil if there is none. The
+// messages that expired or are corrupt are discarded. It returns the same
+// message until advance is called.
+func (s *spool) next() []byte {
+	for len(s.segments) > 0 {
+		seg := s.segments[0]
+		if s.offset >= seg.size {
+			if len(s.segments) == 1 && s.active != nil {
+				return nil
+			}
+			s.removeFirst()
+			continue
+		}
+
+		if s.reader == nil {
+			f, err := os.Open(s.path(seg.seq))
+			if err != nil {
+				s.removeFirst()
+				continue
+			}
+			s.reader = f
+		}
+		b, t, err := readSpoolEntry(io.NewSectionReader(s.reader, s.offset, seg.size-s.offset))
+		if err != nil {
+			// The entries that follow can't be found.
+			s.discard(seg.entries)
+			seg.entries = 0
+			s.offset = seg.size
+			continue
+		}
+		s.n = int64(spoolHeaderSize + len(b))
+		if s.opts.MaxAge >= 0 && time.Since(t) > s.opts.MaxAge {
+			s.discard(1)
+			s.consume()
+			continue
+		}
+
+		return b
+	}
+
+	return nil
+}
+
+// advance moves past the message returned by next once replayed. The segment
+// files are removed once they are all replayed.
+func (s *spool) advance() {
+	s.count--
+	s.stats.queued.Add(-1)
+	s.consume()
+	if s.count == 0 {
+		s.removeAll()
+	}
+}
+
+// removeAll removes all the segments.
+func (s *spool) removeAll() {
+	for len(s.segments) > 0 {
+		s.removeFirst()
+	}
+}
+
+// consume moves the cursor past the entry read by next.
+func (s *spool) consume() {
+	s.segments[0].entries--
+	s.offset += s.n
+	s.n = 0
+	s.moved = true
+}
+
+// discard counts the n messages discarded from the spool.
+func (s *spool) discard(n int64) {
+	s.count -= n
+	s.stats.queued.Add(-n)
+	s.stats.dropped[DropSpool].Add(uint64(n))
+}
+
+// removeFirst removes the first segment, discarding the messages that weren't
+// replayed.
+func (s *spool) removeFirst() {
+	seg := s.segments[0]
+	if s.reader != nil {
+		s.reader.Close()
+		s.reader = nil
+	}
+	if len(s.segments) == 1 && s.active != nil {
+		s.active.Close()
+		s.active = nil
+	}
+	os.Remove(s.path(seg.seq))
+
+	s.discard(seg.entries)
+	s.size -= seg.size
+	s.segments = s.segments[1:]
+	s.offset = 0
+	s.moved = true
+}
+
+// saveCursor saves the position of the next message to replay, if it moved,
+// so that the replayed messages aren't replayed again by another process.
+func (s *spool) saveCursor() error {
+	if !s.moved {
+		return nil
+	}
+	s.moved = false
+
+	path := filepath.Join(s.opts.Dir, spoolCursor)
+	if len(s.segments) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+
+	tmp := path + ".tmp"
+	cursor := fmt.Sprintf("%d %d\n", s.segments[0].seq, s.offset)
+	if err := os.WriteFile(tmp, []byte(cursor), 0o600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// start replays the spool with the writer every retry interval until closed.
+func (s *spool) start(w *writer) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.opts.RetryInterval)
+		defer ticker.Stop()
+		for {
+			// The messages left by a previous process are replayed right
+			// away.
+			w.replay()
+			select {
+			case <-ticker.C:
+			case <-s.done:
+				return
+			}
+		}
+	}()
+}
+
+// stopReplay stops the attempts at replaying the spool.
+func (s *spool) stopReplay() {
+	s.stop.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+	})
+}
+
+// close saves the cursor and closes the segment files.
+func (s *spool) close() error {
+	errs := []error{s.saveCursor()}
+	if s.active != nil {
+		errs = append(errs, s.active.Sync(), s.active.Close())
+		s.active = nil
+	}
+	if s.reader != nil {
+		s.reader.Close()
+		s.reader = nil
+	}
+
+	return errors.Join(errs...)
+}
+
+// readSpoolCursor returns the sequence number of the segment being replayed and
+// the offset of its next message, which are zero if unknown.
+func readSpoolCursor(path string) (seq uint64, offset int64) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return 0, 0
+	}
+	if _, err := fmt.Sscanf(string(b), "%d %d", &seq, &offset); err != nil || offset < 0 {
+		return 0, 0
+	}
+
+	return seq, offset
+}
+
+// scanSpoolSegment returns the segment of the file with the sequence number
+// and the offset of its first entry at or after from. The valid entries end at
+// the first one that is corrupt or partial, such as when the process stopped
+// while writing it.
+func scanSpoolSegment(path string, seq uint64, from int64) (seg *spoolSegment, start int64, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	seg = &spoolSegment{seq: seq, modTime: fi.ModTime()}
+	start = -1
+	for {
+		b, _, err := readSpoolEntry(io.NewSectionReader(f, seg.size, fi.Size()-seg.size))
+		if err != nil {
+			break
+		}
+		if seg.size >= from {
+			if start < 0 {
+				start = seg.size
+			}
+			seg.entries++
+		}
+		seg.size += int64(spoolHeaderSize + len(b))
+	}
+	if start < 0 {
+		start = seg.size
+	}
+
+	return seg, start, nil
+}
+
+// readSpoolEntry reads an entry, returning its message and the time it was
+// spooled.
+func readSpoolEntry(r io.Reader) ([]byte, time.Time, error) {
+	var header [spoolHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, time.Time{}, noEOF(err)
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxSpoolEntrySize {
+		return nil, time.Time{}, errSpoolCorrupt
+	}
+
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, time.Time{}, noEOF(err)
+	}
+	crc := crc32.Update(crc32.Checksum(header[8:], spoolTable), spoolTable, b)
+	if crc != binary.BigEndian.Uint32(header[4:]) {
+		return nil, time.Time{}, errSpoolCorrupt
+	}
+
+	return b, time.Unix(0, int64(binary.BigEndian.Uint64(header[8:]))), nil
+}
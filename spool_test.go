@@ -0,0 +1,263 @@
+package slogsyslog
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// unreachableAddress returns the path of a UNIX datagram socket in a temporary
+// directory that nothing listens on yet.
+func unreachableAddress(t *testing.T) string {
+	t.Helper()
+
+	// Socket paths are limited in length, so we can't use the test's
+	// temporary directory.
+	dir, err := os.MkdirTemp("", "slogsyslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	return filepath.Join(dir, "log.sock")
+}
+
+// receiveMessages listens on the UNIX datagram socket and returns the first n
+// messages received once f is called.
+func receiveMessages(t *testing.T, addr string, n int, f func()) []Message {
+	t.Helper()
+
+	pc, err := net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	f()
+
+	msgs := make([]Message, 0, n)
+	b := make([]byte, 64<<10)
+	for len(msgs) < n {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		m, _, err := pc.ReadFrom(b)
+		if err != nil {
+			t.Fatalf("Messages = %d; want %d: %s", len(msgs), n, err)
+		}
+		msg, err := ParseMessage(b[:m])
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, *msg)
+	}
+
+	return msgs
+}
+
+// spoolRecord returns the i-th record, logged i minutes after testTime.
+func spoolRecord(i int) slog.Record {
+	return slog.NewRecord(testTime.Add(time.Duration(i)*time.Minute), slog.LevelInfo, "message "+strconv.Itoa(i), 0)
+}
+
+func TestSyslogHandler_Spool(t *testing.T) {
+	addr := unreachableAddress(t)
+	dir := t.TempDir()
+
+	h, err := New(&Options{
+		Network: "unixgram",
+		Address: addr,
+		Format:  FormatRFC5424,
+		Spool:   &SpoolOptions{Dir: dir, RetryInterval: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("New() = %v; want nil", err)
+	}
+	defer h.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := h.Handle(context.Background(), spoolRecord(i)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	}
+	if st := h.Stats(); st.QueueDepth != 3 || st.Dropped[DropWriteError] != 0 {
+		t.Fatalf("Stats() = %+v; want 3 queued", st)
+	}
+
+	msgs := receiveMessages(t, addr, 4, func() {
+		if err := h.Handle(context.Background(), spoolRecord(3)); err != nil {
+			t.Fatalf("Handle() = %v; want nil", err)
+		}
+	})
+	for i, m := range msgs {
+		if want := spoolRecord(i); m.Text != want.Message || !m.Timestamp.Equal(want.Time) {
+			t.Errorf("Message %d = %s %q; want %s %q", i, m.Timestamp, m.Text, want.Time, want.Message)
+		}
+	}
+	if st := h.Stats(); st.QueueDepth != 0 || st.Written != 4 {
+		t.Errorf("Stats() = %+v; want 4 written and none queued", st)
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 {
+		t.Errorf("Spool files = %v; want none", files)
+	}
+}
+
+func TestSyslogHandler_SpoolRestart(t *testing.T) {
+	addr := unreachableAddress(t)
+	opts := &Options{
+		Network: "unixgram",
+		Address: addr,
+		Format:  FormatRFC5424,
+		Spool:   &SpoolOptions{Dir: t.TempDir(), RetryInterval: time.Hour},
+	}
+
+	h, err := New(opts)
+	if err != nil {
+		t.Fatalf("New() = %v; want nil", err)
+	}
+	for i := 0; i < 2; i++ {
+		h.Handle(context.Background(), spoolRecord(i))
+	}
+	if err := h.Close(); err != nil {
+		t.Fatalf("Close() = %v; want nil", err)
+	}
+
+	// The new handler replays the messages of the previous one right away.
+	var h2 *SyslogHandler
+	msgs := receiveMessages(t, addr, 2, func() {
+		if h2, err = New(opts); err != nil {
+			t.Fatalf("New() = %v; want nil", err)
+		}
+	})
+	defer h2.Close()
+
+	for i, m := range msgs {
+		if want := spoolRecord(i); m.Text != want.Message || !m.Timestamp.Equal(want.Time) {
+			t.Errorf("Message %d = %s %q; want %s %q", i, m.Timestamp, m.Text, want.Time, want.Message)
+		}
+	}
+}
+
+func TestSpool(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		opts SpoolOptions
+		// change changes the spool's files before it is opened again.
+		change  func(t *testing.T, dir string)
+		want    []string
+		dropped uint64
+	}{
+		{
+			name: "Replay",
+			opts: SpoolOptions{SegmentSize: 40},
+			want: []string{"message 0", "message 1", "message 2", "message 3", "message 4"},
+		},
+		{
+			name:    "MaxSize",
+			opts:    SpoolOptions{SegmentSize: 60, MaxSize: 100},
+			want:    []string{"message 2", "message 3", "message 4"},
+			dropped: 2,
+		},
+		{
+			name:    "MaxAge",
+			opts:    SpoolOptions{MaxAge: time.Nanosecond},
+			dropped: 5,
+		},
+		{
+			name: "Corrupt",
+			opts: SpoolOptions{SegmentSize: 60},
+			change: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "00000000000000000001"+spoolSuffix)
+				b, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// The second message of the first segment.
+				b[len(b)-1] ^= 0xff
+				if err := os.WriteFile(path, b, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"message 0", "message 2", "message 3", "message 4"},
+		},
+		{
+			name: "Truncated",
+			change: func(t *testing.T, dir string) {
+				path := filepath.Join(dir, "00000000000000000001"+spoolSuffix)
+				fi, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Truncate(path, fi.Size()-1); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: []string{"message 0", "message 1", "message 2", "message 3"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.opts.Dir = t.TempDir()
+			st := new(stats)
+			s, err := openSpool(tc.opts, st)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				if err := s.append([]byte("message " + strconv.Itoa(i))); err != nil {
+					t.Fatalf("append() = %v; want nil", err)
+				}
+			}
+			if err := s.close(); err != nil {
+				t.Fatalf("close() = %v; want nil", err)
+			}
+			if tc.change != nil {
+				tc.change(t, tc.opts.Dir)
+			}
+
+			// The spool is replayed by processes stopping after two messages.
+			var got []string
+			dropped := st.dropped[DropSpool].Load()
+			for more := true; more; {
+				st = new(stats)
+				s, err := openSpool(tc.opts, st)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for i := 0; i < 2 && more; i++ {
+					b := s.next()
+					if more = b != nil; more {
+						got = append(got, string(b))
+						s.advance()
+					}
+				}
+				if err := s.close(); err != nil {
+					t.Fatalf("close() = %v; want nil", err)
+				}
+				dropped += st.dropped[DropSpool].Load()
+			}
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("Messages = %q; want %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("Message %d = %q; want %q", i, got[i], tc.want[i])
+				}
+			}
+			if dropped != tc.dropped {
+				t.Errorf("Dropped = %d; want %d", dropped, tc.dropped)
+			}
+			if queued := st.queued.Load(); queued != 0 {
+				t.Errorf("Queued = %d; want 0", queued)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package slogsyslog
+
+import (
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// Stats are the counters of a handler and all of the handlers derived from it.
+type Stats struct {
+	// Records is the number of records passed to the handlers.
+	Records uint64
+
+	// Written is the number of messages written, including those reporting
+	// repeated and dropped records.
+	Written uint64
+
+	// Bytes is the number of bytes of the messages written, excluding their
+	// framing.
+	Bytes uint64
+
+	// WriteErrors is the number of failed attempts at writing a message or
+	// connecting to write it, including those that succeeded after
+	// reconnecting.
+	WriteErrors uint64
+
+	// Timeouts is the number of writes and connection attempts that timed
+	// out.
+	Timeouts uint64
+
+	// Reconnects is the number of times the connection was established again.
+	Reconnects uint64
+
+	// Dropped is the number of records not written by reason.
+	Dropped map[DropReason]uint64
+
+	// QueueDepth is the number of messages waiting to be written, which are
+	// those in the spool.
+	QueueDepth int64
+}
+
+// stats are the counters shared by the handler, its writer and the handlers
+// derived from it.
+type stats struct {
+	records     atomic.Uint64
+	written     atomic.Uint64
+	bytes       atomic.Uint64
+	writeErrors atomic.Uint64
+	timeouts    atomic.Uint64
+	reconnects  atomic.Uint64
+	dropped     [dropReasons]atomic.Uint64
+	queued      atomic.Int64
+}
+
+// writeError counts a failed write or connection attempt.
+func (st *stats) writeError(err error) {
+	st.writeErrors.Add(1)
+	st.timeout(err)
+}
+
+// timeout counts the error if it is a timeout.
+func (st *stats) timeout(err error) {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		st.timeouts.Add(1)
+	}
+}
+
+// snapshot returns the current values of the counters.
+func (st *stats) snapshot() Stats {
+	s := Stats{
+		Records:     st.records.Load(),
+		Written:     st.written.Load(),
+		Bytes:       st.bytes.Load(),
+		WriteErrors: st.writeErrors.Load(),
+		Timeouts:    st.timeouts.Load(),
+		Reconnects:  st.reconnects.Load(),
+		Dropped:     make(map[DropReason]uint64, dropReasons),
+		QueueDepth:  st.queued.Load(),
+	}
+	for i := range st.dropped {
+		s.Dropped[DropReason(i)] = st.dropped[i].Load()
+	}
+
+	return s
+}
+
+// Stats returns the counters of the handler, which are shared with the root
+// handler and all of the handlers derived from it.
+func (s *SyslogHandler) Stats() Stats {
+	return s.stats.snapshot()
+}
+
+// PublishExpvar publishes the handler's counters as an [expvar] variable of the
+// name. Like [expvar.Publish], it panics if the name is already in use.
+func (s *SyslogHandler) PublishExpvar(name string) {
+	expvar.Publish(name, expvar.Func(func() any {
+		return s.Stats()
+	}))
+}
+
+// MetricsHandler returns an HTTP handler serving the handler's counters in the
+// Prometheus text exposition format.
+func (s *SyslogHandler) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write(appendMetrics(nil, s.Stats()))
+	})
+}
+
+// appendMetrics adds the counters in the Prometheus text exposition format.
+func appendMetrics(buf []byte, s Stats) []byte {
+	for _, m := range [...]struct {
+		name, help, typ string
+		value           uint64
+	}{
+		{"slogsyslog_records_total", "Records passed to the handler.", "counter", s.Records},
+		{"slogsyslog_written_total", "Messages written.", "counter", s.Written},
+		{"slogsyslog_written_bytes_total", "Bytes of the messages written.", "counter", s.Bytes},
+		{"slogsyslog_write_errors_total", "Failed attempts at writing a message.", "counter", s.WriteErrors},
+		{"slogsyslog_timeouts_total", "Writes and connection attempts that timed out.", "counter", s.Timeouts},
+		{"slogsyslog_reconnects_total", "Times the connection was established again.", "counter", s.Reconnects},
+	} {
+		buf = appendMetricHeader(buf, m.name, m.help, m.typ)
+		buf = append(buf, m.name...)
+		buf = append(buf, ' ')
+		buf = strconv.AppendUint(buf, m.value, 10)
+		buf = append(buf, '\n')
+	}
+
+	buf = appendMetricHeader(buf, "slogsyslog_dropped_total", "Records not written by reason.", "counter")
+	for i := DropReason(0); i < dropReasons; i++ {
+		buf = append(buf, `slogsyslog_dropped_total{reason="`...)
+		buf = append(buf, i.String()...)
+		buf = append(buf, `"} `...)
+		buf = strconv.AppendUint(buf, s.Dropped[i], 10)
+		buf = append(buf, '\n')
+	}
+
+	buf = appendMetricHeader(buf, "slogsyslog_queue_depth", "Messages waiting to be written.", "gauge")
+	buf = append(buf, "slogsyslog_queue_depth "...)
+	buf = strconv.AppendInt(buf, s.QueueDepth, 10)
+
+	return append(buf, '\n')
+}
+
+// appendMetricHeader adds the HELP and TYPE lines of a metric.
+func appendMetricHeader(buf []byte, name, help, typ string) []byte {
+	buf = append(buf, "# HELP "...)
+	buf = append(buf, name...)
+	buf = append(buf, ' ')
+	buf = append(buf, help...)
+	buf = append(buf, "\n# TYPE "...)
+	buf = append(buf, name...)
+	buf = append(buf, ' ')
+	buf = append(buf, typ...)
+
+	return append(buf, '\n')
+}
@@ -0,0 +1,162 @@
+package slogsyslog
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogHandler_Stats(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := New(&Options{Network: "udp", Address: pc.LocalAddr().String(), Tag: "test", Strict: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	d := h.WithGroup("g")
+	for _, msg := range [...]string{"a", "b", "c\x00"} {
+		d.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, msg, 0))
+	}
+
+	s := h.Stats()
+	if s.Records != 3 || s.Written != 2 || s.Bytes == 0 || s.Dropped[DropInvalid] != 1 {
+		t.Errorf("Stats() = %+v; want 3 records, 2 written, some bytes and 1 invalid", s)
+	}
+}
+
+func TestSyslogHandler_Stats_Reconnect(t *testing.T) {
+	dir, err := os.MkdirTemp("", "slogsyslog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	addr := filepath.Join(dir, "log.sock")
+	pc, err := net.ListenPacket("unixgram", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := New(&Options{Network: "unixgram", Address: addr})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	pc.Close()
+	os.Remove(addr)
+	if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "lost", 0)); err == nil {
+		t.Fatal("Handle() with the server down = nil; want error")
+	}
+
+	if pc, err = net.ListenPacket("unixgram", addr); err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelInfo, "found", 0)); err != nil {
+		t.Fatalf("Handle() = %v; want nil", err)
+	}
+
+	s := h.Stats()
+	if s.Written != 1 || s.WriteErrors < 2 || s.Reconnects != 1 || s.Dropped[DropWriteError] != 1 {
+		t.Errorf("Stats() = %+v; want 1 written, 2 write errors or more, 1 reconnect and 1 write error drop", s)
+	}
+}
+
+func TestAppendMetrics(t *testing.T) {
+	s := Stats{
+		Records:     5,
+		Written:     3,
+		Bytes:       120,
+		WriteErrors: 2,
+		Timeouts:    1,
+		Reconnects:  1,
+		Dropped:     map[DropReason]uint64{DropSampled: 2},
+		QueueDepth:  4,
+	}
+
+	want := `# HELP slogsyslog_records_total Records passed to the handler.
+# TYPE slogsyslog_records_total counter
+slogsyslog_records_total 5
+# HELP slogsyslog_written_total Messages written.
+# TYPE slogsyslog_written_total counter
+slogsyslog_written_total 3
+# HELP slogsyslog_written_bytes_total Bytes of the messages written.
+# TYPE slogsyslog_written_bytes_total counter
+slogsyslog_written_bytes_total 120
+# HELP slogsyslog_write_errors_total Failed attempts at writing a message.
+# TYPE slogsyslog_write_errors_total counter
+slogsyslog_write_errors_total 2
+# HELP slogsyslog_timeouts_total Writes and connection attempts that timed out.
+# TYPE slogsyslog_timeouts_total counter
+slogsyslog_timeouts_total 1
+# HELP slogsyslog_reconnects_total Times the connection was established again.
+# TYPE slogsyslog_reconnects_total counter
+slogsyslog_reconnects_total 1
+# HELP slogsyslog_dropped_total Records not written by reason.
+# TYPE slogsyslog_dropped_total counter
+slogsyslog_dropped_total{reason="sampled"} 2
+slogsyslog_dropped_total{reason="repeated"} 0
+slogsyslog_dropped_total{reason="invalid"} 0
+slogsyslog_dropped_total{reason="write_error"} 0
+slogsyslog_dropped_total{reason="spool"} 0
+# HELP slogsyslog_queue_depth Messages waiting to be written.
+# TYPE slogsyslog_queue_depth gauge
+slogsyslog_queue_depth 4
+`
+	if got := string(appendMetrics(nil, s)); got != want {
+		t.Errorf("appendMetrics(nil, %+v) = %s; want %s", s, got, want)
+	}
+}
+
+func TestSyslogHandler_MetricsHandler(t *testing.T) {
+	h := newTestHandler(t, nil)
+	h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "a message", 0))
+
+	rec := httptest.NewRecorder()
+	h.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q; want the Prometheus text format", ct)
+	}
+	if body := rec.Body.String(); !strings.Contains(body, "\nslogsyslog_written_total 1\n") {
+		t.Errorf("Body = %s; want 1 message written", body)
+	}
+}
+
+func TestSyslogHandler_PublishExpvar(t *testing.T) {
+	h := newTestHandler(t, nil)
+	// Variables can't be published twice, which would happen when running the
+	// test more than once.
+	name := "slogsyslog_test_" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	h.PublishExpvar(name)
+	h.Handle(context.Background(), slog.NewRecord(time.Now(), slog.LevelInfo, "a message", 0))
+
+	var s struct {
+		Records uint64
+		Dropped map[string]uint64
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(name).String()), &s); err != nil {
+		t.Fatal(err)
+	}
+	if s.Records != 1 || s.Dropped["sampled"] != 0 {
+		t.Errorf("Stats = %+v; want 1 record and no drops", s)
+	}
+}
@@ -0,0 +1,187 @@
+package slogsyslog
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tlsSuffix is the suffix of the URL schemes connecting over TLS.
+const tlsSuffix = "+tls"
+
+// errUnknownField is returned when setting an unknown URL query field.
+var errUnknownField = errors.New("unknown field")
+
+// NewFromURL creates a new syslog handler from the options parsed by
+// [ParseURL].
+func NewFromURL(rawURL string) (*SyslogHandler, error) {
+	opts, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return New(opts)
+}
+
+// ParseURL parses the options from a URL such as
+// tcp+tls://logs.example.com:6514?facility=local3&tag=api&format=rfc5424,
+// relp://logs.example.com:2514 or unixgram:///dev/log. The scheme is the
+// network, with a +tls suffix to connect over TLS, followed by the host and
+// port or by the path of a UNIX socket. The query may set the following fields:
+//
+//   - facility and format as parsed by [ParseFacility] and [ParseFormat]
+//   - level, by its case-insensitive name
+//   - tag, hostname and sdid
+//   - timeout setting both dial_timeout and write_timeout, as durations
+//   - add_source and strict, as booleans
+func ParseURL(rawURL string) (*Options, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("slogsyslog: invalid URL: %w", err)
+	}
+
+	opts := &Options{Network: u.Scheme}
+	if network, ok := strings.CutSuffix(opts.Network, tlsSuffix); ok {
+		opts.Network = network
+		opts.TLSConfig = &tls.Config{}
+	}
+	switch opts.Network {
+	case "unix", "unixgram":
+		if opts.TLSConfig != nil && opts.Network == "unixgram" {
+			return nil, fmt.Errorf("slogsyslog: invalid network %q in URL", u.Scheme)
+		}
+		opts.Address = u.Path
+	case "tcp", "tcp4", "tcp6", "relp":
+		opts.Address = u.Host
+	case "udp", "udp4", "udp6":
+		if opts.TLSConfig != nil {
+			return nil, fmt.Errorf("slogsyslog: invalid network %q in URL", u.Scheme)
+		}
+		opts.Address = u.Host
+	default:
+		return nil, fmt.Errorf("slogsyslog: invalid network %q in URL", u.Scheme)
+	}
+	if opts.Address == "" {
+		return nil, errors.New("slogsyslog: missing address in URL")
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return nil, fmt.Errorf("slogsyslog: invalid query in URL: %w", err)
+	}
+	fields := make([]string, 0, len(query))
+	for field := range query {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields)
+	for _, field := range fields {
+		value := query.Get(field)
+		if err := opts.setURLField(field, value); err == errUnknownField {
+			return nil, fmt.Errorf("slogsyslog: unknown field %q in URL", field)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return opts, nil
+}
+
+// setURLField sets the option of a URL query field. The errors name the field.
+func (o *Options) setURLField(field, value string) error {
+	var err error
+	switch field {
+	case "facility":
+		o.Facility, err = ParseFacility(value)
+		return err
+	case "format":
+		o.Format, err = ParseFormat(value)
+		return err
+	case "level":
+		var level slog.Level
+		err = level.UnmarshalText([]byte(value))
+		o.Level = level
+	case "tag":
+		o.Tag = value
+	case "hostname":
+		o.Hostname = value
+	case "sdid":
+		o.SDID = value
+	case "timeout":
+		o.DialTimeout, err = time.ParseDuration(value)
+		o.WriteTimeout = o.DialTimeout
+	case "dial_timeout":
+		o.DialTimeout, err = time.ParseDuration(value)
+	case "write_timeout":
+		o.WriteTimeout, err = time.ParseDuration(value)
+	case "add_source":
+		o.AddSource, err = strconv.ParseBool(value)
+	case "strict":
+		o.Strict, err = strconv.ParseBool(value)
+	default:
+		return errUnknownField
+	}
+	if err != nil {
+		return fmt.Errorf("slogsyslog: invalid %s %q: %w", field, value, err)
+	}
+
+	return nil
+}
+
+// String returns the options as a URL parsed by [ParseURL], with the network
+// and address defaulting as in [New]. It is redacted in that only the fields
+// ParseURL sets are written: the TLS configuration, with its certificates and
+// keys, only shows as the +tls suffix of the scheme.
+func (o Options) String() string {
+	network, address := o.endpoint()
+
+	u := &url.URL{Scheme: network}
+	if o.TLSConfig != nil {
+		u.Scheme += tlsSuffix
+	}
+	if network == "unix" || network == "unixgram" {
+		u.Path = address
+	} else {
+		u.Host = address
+	}
+
+	query := make(url.Values)
+	if o.Facility > 0 {
+		query.Set("facility", strings.ToLower(o.Facility.String()))
+	}
+	if o.Format != FormatAuto {
+		query.Set("format", strings.ToLower(o.Format.String()))
+	}
+	if o.Level != nil {
+		query.Set("level", strings.ToLower(o.Level.Level().String()))
+	}
+	for field, value := range map[string]string{"tag": o.Tag, "hostname": o.Hostname, "sdid": o.SDID} {
+		if value != "" {
+			query.Set(field, value)
+		}
+	}
+	if o.DialTimeout != 0 && o.DialTimeout == o.WriteTimeout {
+		query.Set("timeout", o.DialTimeout.String())
+	} else {
+		if o.DialTimeout != 0 {
+			query.Set("dial_timeout", o.DialTimeout.String())
+		}
+		if o.WriteTimeout != 0 {
+			query.Set("write_timeout", o.WriteTimeout.String())
+		}
+	}
+	if o.AddSource {
+		query.Set("add_source", "true")
+	}
+	if o.Strict {
+		query.Set("strict", "true")
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
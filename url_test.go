@@ -0,0 +1,183 @@
+package slogsyslog
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseURL(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		url  string
+		want Options
+	}{
+		{
+			name: "TLS",
+			url:  "tcp+tls://logs.internal:6514?facility=local3&tag=api&format=rfc5424&level=warn&timeout=2s",
+			want: Options{
+				Network:      "tcp",
+				Address:      "logs.internal:6514",
+				TLSConfig:    &tls.Config{},
+				Facility:     Local3,
+				Tag:          "api",
+				Format:       FormatRFC5424,
+				Level:        slog.LevelWarn,
+				DialTimeout:  2 * time.Second,
+				WriteTimeout: 2 * time.Second,
+			},
+		},
+		{
+			name: "UNIX",
+			url:  "unixgram:///dev/log",
+			want: Options{Network: "unixgram", Address: "/dev/log"},
+		},
+		{
+			name: "RELP",
+			url:  "relp+tls://logs.internal:2514",
+			want: Options{Network: "relp", Address: "logs.internal:2514", TLSConfig: &tls.Config{}},
+		},
+		{
+			name: "Fields",
+			url:  "udp://127.0.0.1:514?facility=AUTHPRIV&hostname=h&sdid=x@1&dial_timeout=1s&write_timeout=3s&add_source=true&strict=1",
+			want: Options{
+				Network:      "udp",
+				Address:      "127.0.0.1:514",
+				Facility:     AuthPriv,
+				Hostname:     "h",
+				SDID:         "x@1",
+				DialTimeout:  time.Second,
+				WriteTimeout: 3 * time.Second,
+				AddSource:    true,
+				Strict:       true,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseURL(tc.url)
+			if err != nil {
+				t.Fatalf("ParseURL(%q) = %v; want nil", tc.url, err)
+			}
+			if !reflect.DeepEqual(*got, tc.want) {
+				t.Errorf("ParseURL(%q) = %+v; want %+v", tc.url, *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseURL_Invalid(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "Network", url: "http://localhost:514", want: `invalid network "http"`},
+		{name: "TLSOverUDP", url: "udp+tls://localhost:514", want: `invalid network "udp+tls"`},
+		{name: "Address", url: "tcp://", want: "missing address"},
+		{name: "Facility", url: "udp://localhost:514?facility=local8", want: `unknown facility "local8"`},
+		{name: "Format", url: "udp://localhost:514?format=xml", want: `unknown format "xml"`},
+		{name: "Level", url: "udp://localhost:514?level=loud", want: `invalid level "loud"`},
+		{name: "Timeout", url: "udp://localhost:514?timeout=2", want: `invalid timeout "2"`},
+		{name: "Bool", url: "udp://localhost:514?strict=maybe", want: `invalid strict "maybe"`},
+		{name: "Unknown", url: "udp://localhost:514?facilty=user", want: `unknown field "facilty"`},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ParseURL(tc.url); err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("ParseURL(%q) = %v; want error containing %q", tc.url, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestOptions_String(t *testing.T) {
+	testCases := [...]struct {
+		name string
+		opts Options
+		want string
+	}{
+		{
+			name: "Defaults",
+			want: "unixgram:///dev/log",
+		},
+		{
+			name: "GELF",
+			opts: Options{Format: FormatGELF},
+			want: "udp://localhost:12201?format=gelf",
+		},
+		{
+			name: "TLS",
+			opts: Options{
+				Network:      "tcp",
+				Address:      "logs.internal:6514",
+				TLSConfig:    &tls.Config{Certificates: []tls.Certificate{{}}},
+				Facility:     Local3,
+				Tag:          "api",
+				Format:       FormatRFC5424,
+				Level:        slog.LevelWarn,
+				DialTimeout:  2 * time.Second,
+				WriteTimeout: 2 * time.Second,
+			},
+			want: "tcp+tls://logs.internal:6514?facility=local3&format=rfc5424&level=warn&tag=api&timeout=2s",
+		},
+		{
+			name: "Timeouts",
+			opts: Options{Network: "udp", Address: "127.0.0.1:514", DialTimeout: time.Second, Strict: true},
+			want: "udp://127.0.0.1:514?dial_timeout=1s&strict=true",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := tc.opts.String()
+			if got != tc.want {
+				t.Errorf("String() = %q; want %q", got, tc.want)
+			}
+			if _, err := ParseURL(got); err != nil {
+				t.Errorf("ParseURL(%q) = %v; want nil", got, err)
+			}
+		})
+	}
+}
+
+func TestNewFromURL(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	h, err := NewFromURL("udp://" + pc.LocalAddr().String() + "?format=rfc3164&tag=api&level=warn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+
+	ctx := context.Background()
+	if h.Enabled(ctx, slog.LevelInfo) {
+		t.Errorf("Enabled(%v) = true; want false", slog.LevelInfo)
+	}
+	if err := h.Handle(ctx, slog.NewRecord(time.Now(), slog.LevelWarn, "a message", 0)); err != nil {
+		t.Fatal(err)
+	}
+	if got := readMessages(t, pc, 1); got[0] != "a message" {
+		t.Errorf("Message = %q; want %q", got[0], "a message")
+	}
+}
@@ -0,0 +1,293 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// errInvalidSignature is returned when a block's signature doesn't match.
+var errInvalidSignature = errors.New("slogsyslog: invalid signature")
+
+// VerifyResult is the result of the verification of signed messages.
+type VerifyResult struct {
+	// Verified is the number of messages whose hash is in a valid signature
+	// block.
+	Verified int
+
+	// Unverified are the indices of the messages, counting all the messages
+	// added, whose hash isn't in any valid signature block. They were
+	// inserted or tampered with, unless their signature block was lost or is
+	// yet to come.
+	Unverified []int
+
+	// Missing are the numbers of the messages, counting from 1 in every
+	// session, whose hash is in a valid signature block but that weren't
+	// added. They were lost or tampered with.
+	Missing []uint64
+
+	// MissingBlocks are the global block counters of the signature blocks
+	// that weren't added.
+	MissingBlocks []uint64
+
+	// InvalidBlocks are the indices of the signature and certificate blocks
+	// that are malformed, whose signature is invalid, that were added before
+	// the key is known or that were added before.
+	InvalidBlocks []int
+}
+
+// OK reports whether all the messages are verified and none are missing.
+func (r *VerifyResult) OK() bool {
+	return len(r.Unverified) == 0 && len(r.Missing) == 0 && len(r.MissingBlocks) == 0 && len(r.InvalidBlocks) == 0
+}
+
+// Verifier verifies messages signed as described in RFC 5848 by a handler
+// with [SigningOptions]. Messages are added in the order they were sent,
+// starting with the first one of the sender's session, as received without
+// their transport framing.
+type Verifier struct {
+	// key verifies the signatures. When nil, it is taken from the first
+	// complete certificate blocks.
+	key crypto.PublicKey
+
+	// n is the index of the next message.
+	n int
+
+	// pending are the indices of the messages yet to be verified by hash.
+	pending map[string][]int
+
+	// rsid is the reboot session ID of the last signature block and gbc its
+	// global block counter.
+	rsid string
+	gbc  uint64
+
+	// payload is the payload block being assembled from the certificate
+	// blocks whose messages and indices are certs.
+	payload []byte
+	certs   []certBlock
+
+	result VerifyResult
+}
+
+// certBlock is a certificate block waiting for the key to be verified, along
+// with its VER parameter.
+type certBlock struct {
+	i   int
+	ver string
+	msg []byte
+}
+
+// NewVerifier returns a verifier of the messages signed by the private key of
+// the public key. If nil, the key sent in the certificate blocks is trusted.
+func NewVerifier(key crypto.PublicKey) *Verifier {
+	return &Verifier{key: key, pending: make(map[string][]int)}
+}
+
+// Add adds the next message.
+func (v *Verifier) Add(msg []byte) {
+	i := v.n
+	v.n++
+
+	if m, err := ParseMessage(msg); err == nil && len(m.StructuredData) > 0 {
+		switch m.StructuredData[0].ID {
+		case "ssign":
+			v.addSignatureBlock(i, msg, m.StructuredData[0])
+			return
+		case "ssign-cert":
+			v.addCertificateBlock(i, msg, m.StructuredData[0])
+			return
+		}
+	}
+
+	sum := sha256.Sum256(msg)
+	key := string(sum[:])
+	v.pending[key] = append(v.pending[key], i)
+}
+
+// Result returns the result of the verification of the messages added so far.
+// Messages are verified by the signature blocks following them, so it should
+// be called after the last one, such as when the handler was closed.
+func (v *Verifier) Result() VerifyResult {
+	r := v.result
+	r.Unverified = nil
+	for _, indices := range v.pending {
+		r.Unverified = append(r.Unverified, indices...)
+	}
+	slices.Sort(r.Unverified)
+
+	return r
+}
+
+// addSignatureBlock verifies the messages whose hashes are in the block.
+func (v *Verifier) addSignatureBlock(i int, msg []byte, e SDElement) {
+	params := blockParams(e)
+	gbc, err1 := strconv.ParseUint(params["GBC"], 10, 64)
+	fmn, err2 := strconv.ParseUint(params["FMN"], 10, 64)
+	cnt, err3 := strconv.Atoi(params["CNT"])
+	hashes := strings.Fields(params["HB"])
+	if v.key == nil || params["VER"] != signatureVersion(v.key) || errors.Join(err1, err2, err3) != nil ||
+		cnt != len(hashes) || verifyBlock(v.key, msg) != nil {
+		v.result.InvalidBlocks = append(v.result.InvalidBlocks, i)
+		return
+	}
+
+	if rsid := params["RSID"]; rsid != v.rsid {
+		v.rsid, v.gbc = rsid, 0
+	}
+	if gbc <= v.gbc {
+		v.result.InvalidBlocks = append(v.result.InvalidBlocks, i)
+		return
+	}
+	for missing := v.gbc + 1; missing < gbc; missing++ {
+		v.result.MissingBlocks = append(v.result.MissingBlocks, missing)
+	}
+	v.gbc = gbc
+
+	for j, h := range hashes {
+		sum, err := base64.StdEncoding.DecodeString(h)
+		key := string(sum)
+		if err != nil || len(v.pending[key]) == 0 {
+			v.result.Missing = append(v.result.Missing, fmn+uint64(j))
+			continue
+		}
+
+		v.pending[key] = v.pending[key][1:]
+		if len(v.pending[key]) == 0 {
+			delete(v.pending, key)
+		}
+		v.result.Verified++
+	}
+}
+
+// addCertificateBlock adds the fragment of the payload block holding the key,
+// which is taken once complete unless the verifier was given a key.
+func (v *Verifier) addCertificateBlock(i int, msg []byte, e SDElement) {
+	params := blockParams(e)
+	tbpl, err1 := strconv.Atoi(params["TBPL"])
+	index, err2 := strconv.Atoi(params["INDEX"])
+	flen, err3 := strconv.Atoi(params["FLEN"])
+	frag := params["FRAG"]
+	if errors.Join(err1, err2, err3) != nil || flen != len(frag) {
+		v.result.InvalidBlocks = append(v.result.InvalidBlocks, i)
+		return
+	}
+
+	if v.key != nil {
+		if params["VER"] != signatureVersion(v.key) || verifyBlock(v.key, msg) != nil {
+			v.result.InvalidBlocks = append(v.result.InvalidBlocks, i)
+		}
+		return
+	}
+
+	// Fragments are expected in order, starting over with the first one.
+	if index == 1 {
+		v.payload, v.certs = v.payload[:0], v.certs[:0]
+	}
+	if index != len(v.payload)+1 || len(v.payload)+flen > tbpl {
+		v.result.InvalidBlocks = append(v.result.InvalidBlocks, i)
+		return
+	}
+	v.payload = append(v.payload, frag...)
+	v.certs = append(v.certs, certBlock{i, params["VER"], bytes.Clone(msg)})
+	if len(v.payload) < tbpl {
+		return
+	}
+
+	key, err := payloadKey(string(v.payload))
+	valid := err == nil
+	for _, c := range v.certs {
+		if err != nil || c.ver != signatureVersion(key) || verifyBlock(key, c.msg) != nil {
+			v.result.InvalidBlocks = append(v.result.InvalidBlocks, c.i)
+			valid = false
+		}
+	}
+	if valid {
+		v.key = key
+	}
+	v.payload, v.certs = v.payload[:0], v.certs[:0]
+}
+
+// blockParams returns the parameters of the block by name.
+func blockParams(e SDElement) map[string]string {
+	params := make(map[string]string, len(e.Params))
+	for _, p := range e.Params {
+		params[p.Name] = p.Value
+	}
+
+	return params
+}
+
+// payloadKey returns the public key of the payload block, which is its time,
+// the type of its key blob and the blob encoded in base64 separated by spaces.
+func payloadKey(payload string) (crypto.PublicKey, error) {
+	fields := strings.Fields(payload)
+	if len(fields) != 3 {
+		return nil, ErrMalformed
+	}
+	blob, err := base64.StdEncoding.DecodeString(fields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	switch fields[1] {
+	case "K":
+		return x509.ParsePKIXPublicKey(blob)
+	case "C":
+		cert, err := x509.ParseCertificate(blob)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	default:
+		return nil, ErrMalformed
+	}
+}
+
+// verifyBlock verifies the signature of the block message, which is that of
+// the message with an empty SIGN parameter.
+func verifyBlock(key crypto.PublicKey, msg []byte) error {
+	msg = bytes.TrimRight(msg, "\r\n\x00")
+	start := bytes.LastIndex(msg, []byte(` SIGN="`))
+	if start < 0 {
+		return ErrMalformed
+	}
+	start += len(` SIGN="`)
+	end := bytes.IndexByte(msg[start:], '"')
+	if end < 0 {
+		return ErrMalformed
+	}
+	end += start
+
+	sig, err := base64.StdEncoding.DecodeString(string(msg[start:end]))
+	if err != nil {
+		return err
+	}
+	signed := append(slices.Clip(msg[:start]), msg[end:]...)
+
+	digest := sha256.Sum256(signed)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sig) {
+			return errInvalidSignature
+		}
+		return nil
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, signed, sig) {
+			return errInvalidSignature
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig)
+	default:
+		return errors.New("slogsyslog: unsupported signing key")
+	}
+}
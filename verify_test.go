@@ -0,0 +1,131 @@
+package slogsyslog
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A certificate block, records 0 to 2, a signature block, records 3 and 4
+	// and a signature block.
+	msgs := signedMessages(t, SigningOptions{Signer: key, BlockSize: 3}, 5)
+	if len(msgs) != 8 {
+		t.Fatalf("Messages = %d; want 8", len(msgs))
+	}
+
+	testCases := [...]struct {
+		name   string
+		key    crypto.PublicKey
+		change func(msgs [][]byte) [][]byte
+		want   VerifyResult
+	}{
+		{
+			name: "Intact",
+			key:  key.Public(),
+			want: VerifyResult{Verified: 5},
+		},
+		{
+			name: "TrustedCertificate",
+			want: VerifyResult{Verified: 5},
+		},
+		{
+			name: "TamperedMessage",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				msgs[2] = bytes.Replace(msgs[2], []byte("message 1"), []byte("message 9"), 1)
+				return msgs
+			},
+			want: VerifyResult{Verified: 4, Unverified: []int{2}, Missing: []uint64{2}},
+		},
+		{
+			name: "DroppedMessage",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				return slices.Delete(msgs, 5, 6)
+			},
+			want: VerifyResult{Verified: 4, Missing: []uint64{4}},
+		},
+		{
+			name: "InsertedMessage",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				return slices.Insert(msgs, 2, bytes.Replace(msgs[1], []byte("message 0"), []byte("forged"), 1))
+			},
+			want: VerifyResult{Verified: 5, Unverified: []int{2}},
+		},
+		{
+			name: "DroppedBlock",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				return slices.Delete(msgs, 4, 5)
+			},
+			want: VerifyResult{Verified: 2, Unverified: []int{1, 2, 3}, MissingBlocks: []uint64{1}},
+		},
+		{
+			name: "TamperedBlock",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				msgs[7] = bytes.Replace(msgs[7], []byte(`CNT="2"`), []byte(`CNT="1"`), 1)
+				return msgs
+			},
+			want: VerifyResult{Verified: 3, Unverified: []int{5, 6}, InvalidBlocks: []int{7}},
+		},
+		{
+			name: "DSAScheme",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				msgs[4] = bytes.Replace(msgs[4], []byte(`VER="0127"`), []byte(`VER="0121"`), 1)
+				return msgs
+			},
+			want: VerifyResult{Verified: 2, Unverified: []int{1, 2, 3}, MissingBlocks: []uint64{1}, InvalidBlocks: []int{4}},
+		},
+		{
+			name: "ReplayedBlock",
+			key:  key.Public(),
+			change: func(msgs [][]byte) [][]byte {
+				return append(msgs, msgs[4])
+			},
+			want: VerifyResult{Verified: 5, InvalidBlocks: []int{8}},
+		},
+		{
+			name: "OtherKey",
+			key:  other.Public(),
+			want: VerifyResult{Unverified: []int{1, 2, 3, 5, 6}, InvalidBlocks: []int{0, 4, 7}},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			msgs := slices.Clone(msgs)
+			if tc.change != nil {
+				msgs = tc.change(msgs)
+			}
+
+			v := NewVerifier(tc.key)
+			for _, m := range msgs {
+				v.Add(m)
+			}
+			if got := v.Result(); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("Result() = %+v; want %+v", got, tc.want)
+			}
+		})
+	}
+}